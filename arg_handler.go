@@ -1,37 +1,57 @@
 package clingy
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/zeebo/errs/v2"
 )
 
 type argsHandler struct {
-	args    []string
-	used    []bool
-	dynamic func(string) ([]string, error)
-	getenv  func(string) string
+	args      []string
+	used      []bool
+	dynamic   DynamicSource
+	sources   []ValueSource // if nil, DefaultSources
+	getenv    func(string) string
+	stdin     io.Reader
+	stdinBy   string // name of the parameter that consumed stdin
+	prompt    *prompter
+	last      int    // index of the last consumed argument
+	consumed  []int  // indexes of the arguments consumed by flags, in order
+	stdinData string // what was read from stdin by the parameter that consumed it
+	raw       int    // index of the first argument never interpreted as a flag, if non-negative
+	dir       string // if set, the directory relative files are read from
+	noRead    bool   // if set, values are not read from files or stdin
 }
 
-func newArgsHandler(args []string, dynamic func(string) ([]string, error), getenv func(string) string) *argsHandler {
+func newArgsHandler(args []string, dynamic DynamicSource, getenv func(string) string, stdin io.Reader) *argsHandler {
 	return &argsHandler{
 		args:    args,
 		used:    make([]bool, len(args)),
 		dynamic: dynamic,
 		getenv:  getenv,
+		stdin:   stdin,
+		last:    -1,
+		raw:     -1,
 	}
 }
 
+func (ah *argsHandler) isRaw(i int) bool { return ah.raw >= 0 && i >= ah.raw }
+
 func (ah *argsHandler) PeekArgs() []string {
 	sep := false
 	out := make([]string, 0, len(ah.args))
 	for i, arg := range ah.args {
-		if arg == "--" {
+		if arg == "--" && !ah.isRaw(i) {
 			sep = true
 			continue
 		} else if ah.used[i] {
 			continue
-		} else if !sep && len(arg) > 1 && arg[0] == '-' {
+		} else if !sep && !ah.isRaw(i) && len(arg) > 1 && arg[0] == '-' {
 			continue
 		}
 		out = append(out, arg)
@@ -43,13 +63,17 @@ func (ah *argsHandler) ConsumeArgs() ([]string, error) {
 	sep := false
 	out := make([]string, 0, len(ah.args))
 	for i, arg := range ah.args {
-		if arg == "--" {
+		if arg == "--" && !ah.isRaw(i) {
 			sep = true
 			continue
 		} else if ah.used[i] {
 			continue
-		} else if !sep && len(arg) > 1 && arg[0] == '-' {
-			return nil, errs.Tag("argument error").Errorf("unknown flag: %q", arg)
+		} else if !sep && !ah.isRaw(i) && len(arg) > 1 && arg[0] == '-' {
+			return nil, errs.Tag("argument error").Wrap(&UsageError{
+				Kind:  UnknownFlag,
+				Value: arg,
+				msg:   fmt.Sprintf("unknown flag: %q", arg),
+			})
 		}
 		out = append(out, arg)
 	}
@@ -62,13 +86,17 @@ func (ah *argsHandler) ConsumeArgs() ([]string, error) {
 func (ah *argsHandler) PeekArg() (string, bool, error) {
 	sep := false
 	for i, arg := range ah.args {
-		if arg == "--" {
+		if arg == "--" && !ah.isRaw(i) {
 			sep = true
 			continue
 		} else if ah.used[i] {
 			continue
-		} else if !sep && len(arg) > 1 && arg[0] == '-' {
-			return "", false, errs.Tag("argument error").Errorf("unknown flag: %q", arg)
+		} else if !sep && !ah.isRaw(i) && len(arg) > 1 && arg[0] == '-' {
+			return "", false, errs.Tag("argument error").Wrap(&UsageError{
+				Kind:  UnknownFlag,
+				Value: arg,
+				msg:   fmt.Sprintf("unknown flag: %q", arg),
+			})
 		}
 		return arg, true, nil
 	}
@@ -78,28 +106,33 @@ func (ah *argsHandler) PeekArg() (string, bool, error) {
 func (ah *argsHandler) ConsumeArg() (string, bool, error) {
 	sep := false
 	for i, arg := range ah.args {
-		if arg == "--" {
+		if arg == "--" && !ah.isRaw(i) {
 			sep = true
 			continue
 		} else if ah.used[i] {
 			continue
-		} else if !sep && len(arg) > 1 && arg[0] == '-' {
-			return "", false, errs.Tag("argument error").Errorf("unknown flag: %q", arg)
+		} else if !sep && !ah.isRaw(i) && len(arg) > 1 && arg[0] == '-' {
+			return "", false, errs.Tag("argument error").Wrap(&UsageError{
+				Kind:  UnknownFlag,
+				Value: arg,
+				msg:   fmt.Sprintf("unknown flag: %q", arg),
+			})
 		}
 		ah.used[i] = true
+		ah.last = i
 		return arg, true, nil
 	}
 	return "", false, nil
 }
 
-func (ah *argsHandler) ConsumeFlag(name string, bstyle bool, getenv string) (values []string, err error) {
+func (ah *argsHandler) ConsumeFlag(name string, bstyle bool) (values []string, err error) {
 	var used []uint
 
 	for i := uint(0); i < uint(len(ah.args)); i++ {
 		arg := ah.args[i]
 
 		// check if the argument ends all flags
-		if arg == "--" {
+		if arg == "--" || ah.isRaw(int(i)) {
 			break
 		}
 
@@ -135,8 +168,12 @@ func (ah *argsHandler) ConsumeFlag(name string, bstyle bool, getenv string) (val
 		}
 
 		// if we don't have a value specified, we have an error
-		if i+1 >= uint(len(ah.args)) || ah.used[i+1] || ah.args[i+1] == "--" {
-			return nil, errs.Tag("argument error").Errorf("no value for flag %q", name)
+		if i+1 >= uint(len(ah.args)) || ah.used[i+1] || ah.args[i+1] == "--" || ah.isRaw(int(i+1)) {
+			return nil, errs.Tag("argument error").Wrap(&UsageError{
+				Kind:  MissingValue,
+				Param: name,
+				msg:   fmt.Sprintf("no value for flag %q", name),
+			})
 		}
 
 		// consume the next argument as the flag value
@@ -145,21 +182,122 @@ func (ah *argsHandler) ConsumeFlag(name string, bstyle bool, getenv string) (val
 		i++
 	}
 
-	// if the flag was not found and we have a getenv, try
-	if values == nil && getenv != "" && ah.getenv != nil {
-		if val := ah.getenv(getenv); val != "" {
-			values = append(values, val)
+	for _, i := range used {
+		ah.used[i] = true
+		ah.consumed = append(ah.consumed, int(i))
+	}
+
+	return values, nil
+}
+
+// consume marks the arguments at the indexes as consumed by a flag.
+func (ah *argsHandler) consume(idxs []int) {
+	for _, i := range idxs {
+		ah.used[i] = true
+	}
+	ah.consumed = append(ah.consumed, idxs...)
+}
+
+// consumedFrom returns true if any argument at or after the index was consumed
+// by a flag.
+func (ah *argsHandler) consumedFrom(i int) bool { return consumedFrom(ah.consumed, i) }
+
+func consumedFrom(idxs []int, i int) bool {
+	for _, j := range idxs {
+		if j >= i {
+			return true
 		}
 	}
+	return false
+}
 
-	// if the flag was not found, try calling the dynamic callback
-	if values == nil && ah.dynamic != nil {
-		return ah.dynamic(name)
+// LookupDynamic returns the values of the flag from the dynamic source, or nil
+// if there is no source or it has no values for the flag.
+func (ah *argsHandler) LookupDynamic(flag DynamicFlag) ([]string, error) {
+	if ah.dynamic == nil {
+		return nil, nil
+	}
+	vals, err := ah.dynamic.Lookup(flag)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
 	}
+	return vals, nil
+}
 
-	for _, i := range used {
-		ah.used[i] = true
+// LookupEnv returns the values of the environment variable, split by sep if it
+// is not empty, or nil if there is no key or the variable is empty.
+func (ah *argsHandler) LookupEnv(key, sep string) []string {
+	if key == "" || ah.getenv == nil {
+		return nil
+	}
+	val := ah.getenv(key)
+	if val == "" {
+		return nil
+	} else if sep == "" {
+		return []string{val}
 	}
 
-	return values, nil
+	var values []string
+	for _, v := range strings.Split(val, sep) {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// RawStart returns the index of the first positional argument after the last
+// consumed argument. Flags for which hasValue returns true take the next
+// argument as their value.
+func (ah *argsHandler) RawStart(hasValue func(name string) bool) int {
+	for i := ah.last + 1; i < len(ah.args); i++ {
+		arg := ah.args[i]
+		if ah.used[i] {
+			continue
+		} else if arg == "--" {
+			return i + 1
+		} else if len(arg) < 2 || arg[0] != '-' {
+			return i
+		}
+
+		name := strings.TrimPrefix(arg[1:], "-")
+		if strings.IndexByte(name, '=') == -1 && hasValue(name) {
+			i++
+		}
+	}
+	return len(ah.args)
+}
+
+func (ah *argsHandler) ReadValue(name, val string) (string, error) {
+	switch {
+	case val == "-":
+		if ah.stdinBy != "" {
+			return "", invalidValue(name, val, nil, "%s: stdin already consumed by %q", name, ah.stdinBy)
+		} else if ah.stdin == nil {
+			return "", invalidValue(name, val, nil, "%s: no stdin available", name)
+		}
+		ah.stdinBy = name
+		data, err := io.ReadAll(ah.stdin)
+		ah.stdinData = string(data)
+		if err != nil {
+			return "", invalidValue(name, val, err, "%s: unable to read stdin: %v", name, err)
+		}
+		return string(data), nil
+
+	case len(val) > 0 && val[0] == '@':
+		path := val[1:]
+		if ah.dir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(ah.dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", invalidValue(name, val, err, "%s: unable to read file: %v", name, err)
+		}
+		return string(data), nil
+
+	default:
+		return val, nil
+	}
 }
@@ -21,17 +21,19 @@ func TestArgHandler(t *testing.T) {
 		"--",            // separator
 		"arg2",          // arg
 		"--foo", "bing", // args
-	}, func(name string) ([]string, error) {
-		if name == "err" {
+	}, DynamicSourceFunc(func(flag DynamicFlag) ([]string, error) {
+		if flag.Name == "err" {
 			return nil, errs.Tag("sentinel")
+		} else if flag.Name == "missing" {
+			return nil, errs.Wrap(ErrNotFound)
 		}
 		return []string{"sym"}, nil
-	}, func(name string) string {
+	}), func(name string) string {
 		if name == "ENV_ENV" {
 			return "envval"
 		}
 		return ""
-	})
+	}, nil)
 
 	{ // first peek doesn't know if "bar" is the value for "--foo" or if "--foo" is boolean
 		assert.DeepEqual(t, ah.PeekArgs(), []string{"bar", "baz", "arg", "arg2", "--foo", "bing"})
@@ -43,57 +45,67 @@ func TestArgHandler(t *testing.T) {
 	}
 
 	{ // parse "--foo", "bar" is removed from args
-		got, err := ah.ConsumeFlag("foo", false, "ENV_FOO")
+		got, err := ah.ConsumeFlag("foo", false)
 		assert.NoError(t, err)
 		assert.DeepEqual(t, got, []string{"bar"})
 		assert.DeepEqual(t, ah.PeekArgs(), []string{"baz", "arg", "arg2", "--foo", "bing"})
 	}
 
 	{ // if "--zap" is not boolean, the final "--zap" has no value associated
-		got, err := ah.ConsumeFlag("zap", false, "ENV_ZAP")
+		got, err := ah.ConsumeFlag("zap", false)
 		assert.That(t, errors.Is(err, errs.Tag("argument error")))
 		assert.Nil(t, got)
 		assert.DeepEqual(t, ah.PeekArgs(), []string{"baz", "arg", "arg2", "--foo", "bing"})
 	}
 
 	{ // parse "--zap" as boolean, getting 3 values
-		got, err := ah.ConsumeFlag("zap", true, "ENV_ZAP")
+		got, err := ah.ConsumeFlag("zap", true)
 		assert.NoError(t, err)
 		assert.DeepEqual(t, got, []string{"true", "false", "true"})
 		assert.DeepEqual(t, ah.PeekArgs(), []string{"baz", "arg", "arg2", "--foo", "bing"})
 	}
 
 	{ // there is no "--baf" flag because it is a potential value to "--bif", so this is an error
-		got, err := ah.ConsumeFlag("baf", false, "ENV_BAF")
+		got, err := ah.ConsumeFlag("baf", false)
 		assert.That(t, errors.Is(err, errs.Tag("argument error")))
 		assert.Nil(t, got)
 		assert.DeepEqual(t, ah.PeekArgs(), []string{"baz", "arg", "arg2", "--foo", "bing"})
 	}
 
 	{ // parse "--bif" consuming the "--baf" value
-		got, err := ah.ConsumeFlag("bif", false, "ENV_BIF")
+		got, err := ah.ConsumeFlag("bif", false)
 		assert.NoError(t, err)
 		assert.DeepEqual(t, got, []string{"--baf"})
 		assert.DeepEqual(t, ah.PeekArgs(), []string{"baz", "arg", "arg2", "--foo", "bing"})
 	}
 
-	{ // ensure that the dynamic callback can be used to successfully return a value
-		got, err := ah.ConsumeFlag("not-exist", false, "ENV_NOT_EXIST")
+	{ // ensure that other sources are not consulted when consuming flags
+		got, err := ah.ConsumeFlag("env", false)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	}
+
+	{ // ensure that the dynamic source can be used to successfully return a value
+		got, err := ah.LookupDynamic(DynamicFlag{Name: "not-exist"})
 		assert.NoError(t, err)
 		assert.DeepEqual(t, got, []string{"sym"})
 		assert.DeepEqual(t, ah.PeekArgs(), []string{"baz", "arg", "arg2", "--foo", "bing"})
 	}
 
-	{ // ensure that the dynamic callback can be used to return an error
-		got, err := ah.ConsumeFlag("err", false, "ENV_ERR")
+	{ // ensure that the dynamic source can be used to return an error
+		got, err := ah.LookupDynamic(DynamicFlag{Name: "err"})
 		assert.That(t, errors.Is(err, errs.Tag("sentinel")))
 		assert.Nil(t, got)
-		assert.DeepEqual(t, ah.PeekArgs(), []string{"baz", "arg", "arg2", "--foo", "bing"})
 	}
 
-	{ // ensure that the environment callback can be used to parse a value
-		got, err := ah.ConsumeFlag("env", false, "ENV_ENV")
+	{ // ensure that the dynamic source can report a flag is not found
+		got, err := ah.LookupDynamic(DynamicFlag{Name: "missing"})
 		assert.NoError(t, err)
+		assert.Nil(t, got)
+	}
+
+	{ // ensure that the environment callback can be used to parse a value
+		got := ah.LookupEnv("ENV_ENV", "")
 		assert.DeepEqual(t, got, []string{"envval"})
 		assert.DeepEqual(t, ah.PeekArgs(), []string{"baz", "arg", "arg2", "--foo", "bing"})
 	}
@@ -112,7 +124,7 @@ func TestArgHandler(t *testing.T) {
 	}
 
 	{ // consume the remaining extra flag
-		got, err := ah.ConsumeFlag("extra", true, "ENV_EXTRA")
+		got, err := ah.ConsumeFlag("extra", true)
 		assert.NoError(t, err)
 		assert.DeepEqual(t, got, []string{"true"})
 		assert.DeepEqual(t, ah.PeekArgs(), []string{"arg", "arg2", "--foo", "bing"})
@@ -16,6 +16,7 @@ type stdioEnvironment struct {
 	stdin  io.Reader
 	stdout io.Writer
 	stderr io.Writer
+	dir    string
 }
 
 // Stdin returns the io.Reader from the Environment associated to the context.
@@ -35,3 +36,11 @@ func Stderr(ctx context.Context) io.Writer {
 	val, _ := ctx.Value(stdioKey).(stdioEnvironment)
 	return val.stderr
 }
+
+// Dir returns the Dir from the Environment associated to the context. Commands
+// should resolve relative paths against it. If it is empty, they are relative
+// to the working directory of the process.
+func Dir(ctx context.Context) string {
+	val, _ := ctx.Value(stdioKey).(stdioEnvironment)
+	return val.dir
+}
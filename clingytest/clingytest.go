@@ -0,0 +1,206 @@
+// Package clingytest provides helpers to test commands built with clingy.
+package clingytest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/clingy"
+)
+
+// update is namespaced so that it does not conflict with an -update flag defined
+// by the tests that use this package.
+var update = flag.Bool("clingytest.update", false, "update golden files instead of comparing against them")
+
+// Result is the outcome of running an Environment with Run.
+type Result struct {
+	t testing.TB
+
+	// Stdout and Stderr are everything written to the respective streams.
+	Stdout string
+	Stderr string
+
+	// Ok and Err are the values returned by Environment.Run.
+	Ok  bool
+	Err error
+
+	// Code is the exit status a main function would use: 0 if Ok is true and
+	// Err is nil, and 1 otherwise.
+	Code int
+
+	// UsageErrors are the errors encountered parsing the arguments.
+	UsageErrors []*clingy.UsageError
+}
+
+// Run runs the commands created by fn in env with the given arguments and
+// captures the result. The Stdout and Stderr of the Environment are replaced.
+// If the Environment has no Stdin, it is empty, and if it has no Getenv, every
+// environment variable is empty so that tests do not depend on the process
+// environment. An OnUsageError hook already present in env is still called.
+func Run(t testing.TB, env clingy.Environment, fn func(clingy.Commands), args ...string) *Result {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	res := &Result{t: t}
+
+	env.Args = append([]string{}, args...) // ensure args is non-nil to avoid default
+	env.Stdout = &stdout
+	env.Stderr = &stderr
+	if env.Stdin == nil {
+		env.Stdin = strings.NewReader("")
+	}
+	if env.Getenv == nil {
+		env.Getenv = func(string) string { return "" }
+	}
+	if env.Name == "" {
+		env.Name = "testcommand"
+	}
+
+	onUsageError := env.OnUsageError
+	env.OnUsageError = func(err *clingy.UsageError) {
+		res.UsageErrors = append(res.UsageErrors, err)
+		if onUsageError != nil {
+			onUsageError(err)
+		}
+	}
+
+	res.Ok, res.Err = env.Run(context.Background(), fn)
+	res.Stdout = stdout.String()
+	res.Stderr = stderr.String()
+	if !res.Ok || res.Err != nil {
+		res.Code = 1
+	}
+	return res
+}
+
+// WithStdin returns a copy of env that reads stdin from the string.
+func WithStdin(env clingy.Environment, stdin string) clingy.Environment {
+	env.Stdin = strings.NewReader(stdin)
+	return env
+}
+
+func (r *Result) logFailed(format string, args ...interface{}) {
+	if r.t.Failed() {
+		r.t.Logf(format, args...)
+	}
+}
+
+// AssertValid fails the test if the arguments did not parse or the command
+// returned an error.
+func (r *Result) AssertValid() *Result {
+	r.t.Helper()
+	defer r.logFailed("stdout:\n%s", r.Stdout)
+	defer r.logFailed("stderr:\n%s", r.Stderr)
+	if !r.Ok {
+		r.t.Error("arguments did not parse")
+	}
+	if r.Err != nil {
+		r.t.Errorf("command returned an error: %+v", r.Err)
+	}
+	return r
+}
+
+// AssertUsageError fails the test unless the arguments failed to parse with an
+// error of the given kind.
+func (r *Result) AssertUsageError(kind clingy.UsageErrorKind) *Result {
+	r.t.Helper()
+	defer r.logFailed("stdout:\n%s", r.Stdout)
+	for _, err := range r.UsageErrors {
+		if err.Kind == kind {
+			return r
+		}
+	}
+	r.t.Errorf("expected a usage error of kind %v, got %v", kind, r.UsageErrors)
+	return r
+}
+
+// AssertError fails the test unless the command returned an error matching
+// target as reported by errors.Is.
+func (r *Result) AssertError(target error) *Result {
+	r.t.Helper()
+	if !errors.Is(r.Err, target) {
+		r.t.Errorf("expected error %v, got %v", target, r.Err)
+	}
+	return r
+}
+
+// AssertStdout fails the test unless stdout is equal to the expected value.
+// The expected value is passed through TrimIndent first, so it may be indented
+// with the test.
+func (r *Result) AssertStdout(stdout string) *Result {
+	r.t.Helper()
+	r.assertEqual("stdout", r.Stdout, TrimIndent(stdout))
+	return r
+}
+
+// AssertStderr is like AssertStdout for stderr.
+func (r *Result) AssertStderr(stderr string) *Result {
+	r.t.Helper()
+	r.assertEqual("stderr", r.Stderr, TrimIndent(stderr))
+	return r
+}
+
+// AssertStdoutContains fails the test unless stdout contains the needle.
+func (r *Result) AssertStdoutContains(needle string) *Result {
+	r.t.Helper()
+	if !strings.Contains(r.Stdout, needle) {
+		r.t.Errorf("stdout does not contain %q:\n%s", needle, r.Stdout)
+	}
+	return r
+}
+
+// AssertGolden fails the test unless stdout is equal to the contents of the
+// file at path. If the test binary is run with -clingytest.update, the file is
+// written with stdout instead.
+func (r *Result) AssertGolden(path string) *Result {
+	r.t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(r.Stdout), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+		return r
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		r.t.Fatalf("%v (run with -clingytest.update to create it)", err)
+	}
+	r.assertEqual("stdout of golden file "+path, r.Stdout, string(data))
+	return r
+}
+
+func (r *Result) assertEqual(what, got, expect string) {
+	r.t.Helper()
+	if got != expect {
+		r.t.Errorf("%s mismatch:\ngot:\n%s\nexpect:\n%s", what, got, expect)
+	}
+}
+
+// TrimIndent removes leading blank lines, trailing whitespace, and the
+// indentation of the first line from every non-blank line.
+func TrimIndent(x string) string {
+	lines := strings.Split(x, "\n")
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	prefix := len(lines[0]) - len(strings.TrimLeft(lines[0], " \t"))
+	for i := range lines {
+		if strings.TrimSpace(lines[i]) != "" {
+			lines[i] = lines[i][prefix:]
+		}
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), " \t")
+}
@@ -0,0 +1,94 @@
+package clingytest_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/zeebo/clingy"
+	"github.com/zeebo/clingy/clingytest"
+)
+
+// tests commonly define their own -update flag, which must not conflict.
+var _ = flag.Bool("update", false, "an update flag defined by the tests")
+
+type cmdAdd struct {
+	a, b int
+}
+
+func (c *cmdAdd) Setup(params clingy.Parameters) {
+	c.a = params.Arg("a", "first number", clingy.Transform(strconv.Atoi)).(int)
+	c.b = params.Arg("b", "second number", clingy.Transform(strconv.Atoi)).(int)
+}
+
+func (c *cmdAdd) Execute(ctx context.Context) error {
+	fmt.Fprintln(clingy.Stdout(ctx), c.a+c.b)
+	return nil
+}
+
+type cmdCat struct{}
+
+func (c *cmdCat) Setup(params clingy.Parameters) {}
+
+func (c *cmdCat) Execute(ctx context.Context) error {
+	_, err := io.Copy(clingy.Stdout(ctx), clingy.Stdin(ctx))
+	return err
+}
+
+var errFail = errors.New("fail")
+
+type cmdFail struct{}
+
+func (c *cmdFail) Setup(params clingy.Parameters) {}
+
+func (c *cmdFail) Execute(ctx context.Context) error { return errFail }
+
+func commands(cmds clingy.Commands) {
+	cmds.New("add", "adds two numbers", new(cmdAdd))
+	cmds.New("cat", "copies stdin to stdout", new(cmdCat))
+	cmds.New("fail", "always fails", new(cmdFail))
+}
+
+func TestRun(t *testing.T) {
+	env := clingy.Environment{Name: "calc"}
+
+	clingytest.Run(t, env, commands, "add", "1", "2").
+		AssertValid().
+		AssertStdout("3\n")
+
+	clingytest.Run(t, clingytest.WithStdin(env, "hello"), commands, "cat").
+		AssertValid().
+		AssertStdout("hello")
+
+	res := clingytest.Run(t, env, commands, "add", "1", "two").
+		AssertUsageError(clingy.InvalidValue).
+		AssertStdoutContains("Errors:")
+	if res.Ok || res.Code != 1 || len(res.UsageErrors) != 1 || res.UsageErrors[0].Value != "two" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	res = clingytest.Run(t, env, commands, "fail").AssertError(errFail)
+	if !res.Ok || res.Code != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestRun_Golden(t *testing.T) {
+	clingytest.Run(t, clingy.Environment{Name: "calc"}, commands, "add", "-h").
+		AssertValid().
+		AssertGolden("testdata/add.golden")
+}
+
+func TestAssertHelpGolden(t *testing.T) {
+	clingytest.AssertHelpGolden(t, clingy.Environment{Name: "calc"}, func(cmds clingy.Commands) {
+		cmds.Flag("verbose", "prints more", false, clingy.Advanced, clingy.Boolean, clingy.Transform(strconv.ParseBool))
+		cmds.Group("math", "math commands", func() {
+			cmds.New("add", "adds two numbers", new(cmdAdd))
+		})
+		cmds.New("cat", "copies stdin to stdout", new(cmdCat))
+	}, "testdata/help")
+}
@@ -0,0 +1,194 @@
+package clingytest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/clingy"
+)
+
+// AssertHelpGolden prints the help for every command and group in the tree
+// created by fn, and for the root, both with and without --advanced, and
+// compares each against a golden file in dir. The files are named after the
+// command path joined by underscores, starting with the Name of the Environment,
+// such as "name_group_command.golden" and "name_group_command.advanced.golden".
+// Differences are reported as line diffs, as are golden files in dir with names
+// of that form that no longer correspond to a command. Other files in dir are
+// left alone.
+//
+// If the test binary is run with -clingytest.update, the golden files are
+// written instead, and stale golden files are removed.
+func AssertHelpGolden(t testing.TB, env clingy.Environment, fn func(clingy.Commands), dir string) {
+	t.Helper()
+
+	if env.Name == "" {
+		env.Name = "testcommand"
+	}
+	if env.UsageWidth == 0 {
+		env.UsageWidth = -1 // terminal sizes should not affect the output
+	}
+
+	// the paths are recorded while printing the help for the root.
+	var paths [][]string
+	want := map[string]string{
+		env.Name + ".golden":          Run(t, env, recordPaths(fn, &paths), "-h").Stdout,
+		env.Name + ".advanced.golden": Run(t, env, fn, "-h", "--advanced").Stdout,
+	}
+	for _, path := range paths {
+		base := strings.Join(append([]string{env.Name}, path...), "_")
+		args := append(path[:len(path):len(path)], "-h")
+		want[base+".golden"] = Run(t, env, fn, args...).Stdout
+		want[base+".advanced.golden"] = Run(t, env, fn, append(args, "--advanced")...).Stdout
+	}
+
+	all, err := filepath.Glob(filepath.Join(dir, "*.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var existing []string
+	for _, path := range all {
+		if name := filepath.Base(path); isHelpGolden(env.Name, name) {
+			existing = append(existing, path)
+		}
+	}
+
+	if *update {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range existing {
+			if _, ok := want[filepath.Base(path)]; !ok {
+				if err := os.Remove(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		for name, out := range want {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(out), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return
+	}
+
+	for _, path := range existing {
+		if _, ok := want[filepath.Base(path)]; !ok {
+			t.Errorf("%s: golden file does not correspond to a command (run with -clingytest.update to remove it)", path)
+		}
+	}
+
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("%v (run with -clingytest.update to create it)", err)
+			continue
+		}
+		if got := want[name]; got != string(data) {
+			t.Errorf("%s: help output changed (run with -clingytest.update to accept it):\n%s", path, lineDiff(string(data), got))
+		}
+	}
+}
+
+// isHelpGolden returns true if the file name is of the form used by
+// AssertHelpGolden for the named binary.
+func isHelpGolden(name, file string) bool {
+	return file == name+".golden" || file == name+".advanced.golden" ||
+		(strings.HasPrefix(file, name+"_") && strings.HasSuffix(file, ".golden"))
+}
+
+// pathRecorder wraps Commands to record the path to every command and group
+// that is defined, in the order they are defined.
+type pathRecorder struct {
+	clingy.Commands
+	path  []string
+	paths *[][]string
+}
+
+// recordPaths returns a function that calls fn with Commands that record the
+// path to every command and group into paths.
+func recordPaths(fn func(clingy.Commands), paths *[][]string) func(clingy.Commands) {
+	return func(cmds clingy.Commands) {
+		*paths = nil
+		if fn != nil {
+			fn(&pathRecorder{Commands: cmds, paths: paths})
+		}
+	}
+}
+
+func (pr *pathRecorder) record(name string) []string {
+	path := append(pr.path[:len(pr.path):len(pr.path)], name)
+	*pr.paths = append(*pr.paths, path)
+	return path
+}
+
+func (pr *pathRecorder) New(name, desc string, cmd clingy.Command, options ...clingy.CommandOption) {
+	pr.record(name)
+	pr.Commands.New(name, desc, cmd, options...)
+}
+
+func (pr *pathRecorder) NewFunc(name, desc string, fn func() clingy.Command, options ...clingy.CommandOption) {
+	pr.record(name)
+	pr.Commands.NewFunc(name, desc, fn, options...)
+}
+
+func (pr *pathRecorder) Group(name, desc string, children func()) {
+	path := pr.record(name)
+	pr.Commands.Group(name, desc, func() {
+		parent := pr.path
+		pr.path = path
+		defer func() { pr.path = parent }()
+		if children != nil {
+			children()
+		}
+	})
+}
+
+// lineDiff returns the lines that differ between a and b, prefixed with "-" if
+// they are only in a and "+" if they are only in b.
+func lineDiff(a, b string) string {
+	al, bl := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of al[i:] and bl[j:].
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			i, j = i+1, j+1
+		case j < len(bl) && (i == len(al) || lcs[i][j+1] >= lcs[i+1][j]):
+			fmt.Fprintf(&out, "+%s\n", bl[j])
+			j++
+		default:
+			fmt.Fprintf(&out, "-%s\n", al[i])
+			i++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,66 @@
+package clingytest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/clingy"
+)
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("a\nb\nc\nd", "a\nc\nx\nd")
+	if expect := "-b\n+x\n"; got != expect {
+		t.Fatalf("got %q, expected %q", got, expect)
+	}
+	if got := lineDiff("same", "same"); got != "" {
+		t.Fatalf("got %q for equal input", got)
+	}
+}
+
+func TestRecordPaths(t *testing.T) {
+	var paths [][]string
+	Run(t, clingy.Environment{}, recordPaths(func(cmds clingy.Commands) {
+		cmds.New("foo", "foo", nil)
+		cmds.Group("bar", "bar", func() {
+			cmds.NewFunc("bar0", "bar0", nil)
+			cmds.Group("baz", "baz", func() {
+				cmds.New("baz0", "baz0", nil)
+			})
+		})
+	}, &paths), "-h")
+
+	got := fmt.Sprint(paths)
+	if expect := "[[foo] [bar] [bar bar0] [bar baz] [bar baz baz0]]"; got != expect {
+		t.Fatalf("got %s, expected %s", got, expect)
+	}
+}
+
+func TestAssertHelpGolden_Update(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"other.golden", "calc_gone.golden", "calculator.golden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	*update = true
+	defer func() { *update = false }()
+	AssertHelpGolden(t, clingy.Environment{Name: "calc"}, func(cmds clingy.Commands) {
+		cmds.New("add", "adds", nil)
+	}, dir)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	got := strings.Join(files, " ")
+	if expect := "calc.advanced.golden calc.golden calc_add.advanced.golden calc_add.golden calculator.golden other.golden"; got != expect {
+		t.Fatalf("got %s, expected %s", got, expect)
+	}
+}
@@ -4,14 +4,40 @@ import (
 	"strings"
 )
 
+type cmdOpts struct {
+	passthrough bool
+	examples    []cmdExample
+}
+
+type cmdExample struct {
+	args string
+	desc string
+}
+
 type cmdDesc struct {
+	cmdOpts
 	name    string
 	short   string
 	long    string
 	cmd     Command
+	newCmd  func() Command
 	subcmds []cmdDesc
 }
 
+// hasCmd returns true if the description has a command that can be executed.
+func (cd *cmdDesc) hasCmd() bool {
+	return cd.cmd != nil || cd.newCmd != nil
+}
+
+// instance returns the command to use for a single dispatch or inspection,
+// creating a fresh one if the command was defined with a factory.
+func (cd *cmdDesc) instance() Command {
+	if cd.newCmd != nil {
+		return cd.newCmd()
+	}
+	return cd.cmd
+}
+
 type commands struct {
 	Flags
 	cur []cmdDesc
@@ -30,14 +56,20 @@ func (cmds *commands) collect(fn func()) (out []cmdDesc) {
 	return out
 }
 
-func (cmds *commands) New(name, desc string, cmd Command) {
-	short, long := parseDesc(desc)
-	cmds.cur = append(cmds.cur, cmdDesc{
-		name:  name,
-		short: short,
-		long:  long,
-		cmd:   cmd,
-	})
+func (cmds *commands) New(name, desc string, cmd Command, options ...CommandOption) {
+	cmds.add(cmdDesc{name: name, cmd: cmd}, desc, options)
+}
+
+func (cmds *commands) NewFunc(name, desc string, fn func() Command, options ...CommandOption) {
+	cmds.add(cmdDesc{name: name, newCmd: fn}, desc, options)
+}
+
+func (cmds *commands) add(cd cmdDesc, desc string, options []CommandOption) {
+	cd.short, cd.long = parseDesc(desc)
+	for _, opt := range options {
+		opt.do(&cd.cmdOpts)
+	}
+	cmds.cur = append(cmds.cur, cd)
 }
 
 func (cmds *commands) Group(name, desc string, children func()) {
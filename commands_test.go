@@ -25,15 +25,15 @@ func TestCollectDescs(t *testing.T) {
 		})
 		cmds.New("foo1", "foo1", nil)
 	}), []cmdDesc{
-		{"foo0", "foo0", "foo0 has a multiline description that will be used\nwhen full help is printed for the command but is\nelided when short help is printed.\n\nthe multiline description is trimmed of space on the left.", nil, nil},
-		{"bar", "bar", "", nil, []cmdDesc{
-			{"bar0", "bar0", "", nil, nil},
-			{"bar1", "bar1", "", nil, nil},
-			{"baz", "baz", "", nil, []cmdDesc{
-				{"baz0", "baz0", "", nil, nil},
+		{name: "foo0", short: "foo0", long: "foo0 has a multiline description that will be used\nwhen full help is printed for the command but is\nelided when short help is printed.\n\nthe multiline description is trimmed of space on the left."},
+		{name: "bar", short: "bar", subcmds: []cmdDesc{
+			{name: "bar0", short: "bar0"},
+			{name: "bar1", short: "bar1"},
+			{name: "baz", short: "baz", subcmds: []cmdDesc{
+				{name: "baz0", short: "baz0"},
 			}},
-			{"bar2", "bar2", "", nil, nil},
+			{name: "bar2", short: "bar2"},
 		}},
-		{"foo1", "foo1", "", nil, nil},
+		{name: "foo1", short: "foo1"},
 	})
 }
@@ -0,0 +1,51 @@
+package clingy
+
+import (
+	"errors"
+)
+
+// ErrNotFound is returned by a DynamicSource that has no value for a flag.
+var ErrNotFound = errors.New("not found")
+
+// DynamicFlag describes a flag whose value is looked up in a DynamicSource.
+type DynamicFlag struct {
+	// Path is the name of the binary followed by the names of the commands that
+	// were matched when the flag was defined. Global flags are defined before any
+	// commands are matched, so their Path only contains the name of the binary.
+	Path []string
+
+	// Name is the name of the flag.
+	Name string
+
+	// Global is true if the flag is a global flag.
+	Global bool
+}
+
+// DynamicSource provides values for flags that were not specified as part of
+// the arguments or by an environment variable.
+type DynamicSource interface {
+	// Lookup returns the values for the flag. If the source has no value for
+	// the flag, it returns an error for which errors.Is(err, ErrNotFound) is true.
+	// Any other error becomes the error for the flag, causing the arguments to
+	// fail to parse.
+	Lookup(flag DynamicFlag) (vals []string, err error)
+}
+
+// DynamicSourceFunc adapts a function into a DynamicSource.
+type DynamicSourceFunc func(flag DynamicFlag) (vals []string, err error)
+
+// Lookup calls fn with the flag.
+func (fn DynamicSourceFunc) Lookup(flag DynamicFlag) ([]string, error) { return fn(flag) }
+
+// dynamicSource returns the DynamicSource for the environment, adapting the
+// Dynamic callback if necessary.
+func (env *Environment) dynamicSource() DynamicSource {
+	if env.DynamicSource != nil {
+		return env.DynamicSource
+	} else if env.Dynamic != nil {
+		return DynamicSourceFunc(func(flag DynamicFlag) ([]string, error) {
+			return env.Dynamic(flag.Name)
+		})
+	}
+	return nil
+}
@@ -19,6 +19,10 @@ type funcCommand struct {
 func (cmd *funcCommand) Setup(params clingy.Parameters)    { cmd.SetupFn(params) }
 func (cmd *funcCommand) Execute(ctx context.Context) error { return cmd.ExecuteFn(ctx) }
 
+type readerFunc func(p []byte) (int, error)
+
+func (fn readerFunc) Read(p []byte) (int, error) { return fn(p) }
+
 func printCommand(name string) *funcCommand {
 	return &funcCommand{
 		SetupFn: func(params clingy.Parameters) {},
@@ -102,6 +106,8 @@ func Env(name string, root clingy.Command, args ...string) clingy.Environment {
 		Name: name,
 		Root: root,
 		Args: append([]string{}, args...), // ensure args is non-nil to avoid default
+
+		Getenv: func(string) string { return "" }, // ensure the process environment is not used
 	}
 }
 
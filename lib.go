@@ -85,6 +85,14 @@ type Command interface {
 	Execute(ctx context.Context) error
 }
 
+// Validator is an optional interface for commands to check the values of their
+// arguments and flags together after they have all been parsed. Validate is called
+// before Execute, or before Wrap if it is set. If it returns an error, the error
+// is printed along with the usage for the command, and the command is not executed.
+type Validator interface {
+	Validate(ctx context.Context) error
+}
+
 // Option is the type for values that control details around argument and flags like
 // their presentation, if they are repeated or optional, etc.
 type Option struct {
@@ -113,22 +121,84 @@ var (
 	// not look at the next positional argument if no value is specified.
 	Boolean = Option{func(po *paramOpts) { po.bstyle = true }}
 
+	// FromFile causes values of the flag or argument of the form "@path" to be
+	// replaced with the contents of the file at path, and the value "-" to be
+	// replaced with the contents of stdin. Stdin may only be consumed by a single
+	// value across all flags and arguments. The contents are passed to any
+	// Transform functions as if they were specified directly. Nothing is read if
+	// help or the summary is requested.
+	FromFile = Option{func(po *paramOpts) { po.file = true }}
+
+	// Prompt causes the user to be asked for the value of the flag or argument if
+	// it is not specified and stdin is a terminal.
+	Prompt = Option{func(po *paramOpts) { po.prompt = true }}
+
+	// Secret causes the input to be masked when the user is asked for the value of
+	// the flag or argument. If the input cannot be masked, the user is not asked.
+	// The default value is redacted in usage information, and the value is redacted
+	// from errors about it, including any UsageError.
+	Secret = Option{func(po *paramOpts) { po.secret = true }}
+
+	// NoEnv prevents the flag from being bound to an environment variable by the
+	// Environment's EnvPrefix.
+	NoEnv = Option{func(po *paramOpts) { po.noenv = true }}
+
 	// Required, when passed for the default value of a flag, causes the flag to be
 	// required and an error to occur if it is not specified.
 	Required = func() interface{} { type anon struct{}; return anon{} }()
 )
 
+// CommandOption is the type for values that control details around how commands
+// are parsed and presented.
+type CommandOption struct {
+	do func(*cmdOpts)
+}
+
+var (
+	// Passthrough causes every argument after the first positional argument of the
+	// command to be passed through without being interpreted as a flag, as if a
+	// "--" had been inserted before it. The arguments, including any "--", can be
+	// captured with a Repeated argument. Flags for the command and global flags must
+	// come before the first positional argument. To find where that is, the command
+	// is set up an extra time with parameters that are discarded.
+	Passthrough = CommandOption{func(co *cmdOpts) { co.passthrough = true }}
+)
+
+// Example documents an example invocation of the command. The args are what
+// follows the name of the command on the command line, and desc explains what
+// the invocation does. Examples are printed in the usage for the command, and
+// in the summary if the Environment enables the --examples flag and it is
+// specified.
+func Example(args, desc string) CommandOption {
+	return CommandOption{func(co *cmdOpts) {
+		co.examples = append(co.examples, cmdExample{args: args, desc: desc})
+	}}
+}
+
 // Short causes the flag to be able to be specified with a single character.
 func Short(c byte) Option {
 	return Option{func(po *paramOpts) { po.short = c }}
 }
 
-// Getenv causes the flag to be loaded with the value of the environment variable
-// if not explicitly specified in the argument list.
+// Getenv causes the flag or argument to be loaded with the value of the environment
+// variable if not explicitly specified in the argument list.
 func Getenv(key string) Option {
 	return Option{func(po *paramOpts) { po.getenv = key }}
 }
 
+// EnvSeparator causes the value of the environment variable of a Repeated flag or
+// argument to be split by sep into multiple values, such as ":" for variables
+// like PATH. Without it, the value of the environment variable is a single value.
+func EnvSeparator(sep string) Option {
+	return Option{func(po *paramOpts) { po.envsep = sep }}
+}
+
+// Enum restricts the values of the flag or argument to one of the choices. The
+// choices are presented as a selection list when the user is asked for the value.
+func Enum(choices ...string) Option {
+	return Option{func(po *paramOpts) { po.enum = append(po.enum, choices...) }}
+}
+
 // Transform takes a list of functions meant to parse and transform a string into some
 // final result type. The functions must be of the form (borrowing generics syntax)
 //
@@ -169,7 +239,8 @@ type Parameters interface {
 	// Arg panics if the same name is defined twice. Arg panics if any arguments
 	// are created after a Repeated argument is created. Arg panics if any arguments
 	// that are not Optional or Repeated are created after an Optional argument is
-	// created.
+	// created. If the Environment's CollectDefinitionErrors is set, these mistakes
+	// are reported as errors instead.
 	Arg(name, desc string, options ...Option) interface{}
 }
 
@@ -183,7 +254,8 @@ type Flags interface {
 	// the flag is required, and an error will occur if it is not specified.
 	//
 	// Flag panics if the same name is defined twice, or if the same Short option
-	// is used twice.
+	// is used twice. If the Environment's CollectDefinitionErrors is set, these
+	// mistakes are reported as errors instead.
 	Flag(name, desc string, def interface{}, options ...Option) interface{}
 
 	// Break inserts a line break in the usage output of the flags.
@@ -196,7 +268,12 @@ type Commands interface {
 	Flags
 
 	// New creates a new command.
-	New(name, desc string, cmd Command)
+	New(name, desc string, cmd Command, options ...CommandOption)
+
+	// NewFunc creates a new command whose instances are created by calling fn.
+	// A fresh instance is created every time the command is dispatched or
+	// inspected, so no state is shared between runs.
+	NewFunc(name, desc string, fn func() Command, options ...CommandOption)
 
 	// Group begins a new command group. Calls to New inside of the children
 	// function are associated with the most recent call to Group.
@@ -219,26 +296,112 @@ type Environment struct {
 	// If empty, os.Args[1:] is used.
 	Args []string
 
-	// Dynamic, if set, is consulted for global flag values if they are not
-	// specified as part of Args. If an error is returned, it will no longer
-	// be consulted, and the error will be returned from Run.
+	// Dynamic, if set, is consulted for flag values if they are not specified
+	// as part of Args. It is only called with the name of the flag. If it returns
+	// no values and no error, the flag is considered not found. Any error becomes
+	// the error for the flag. It is ignored if DynamicSource is set.
 	Dynamic func(name string) (vals []string, err error)
 
+	// DynamicSource, if set, is consulted for global and command flag values if
+	// they are not specified as part of Args or by an environment variable. It is
+	// given the path of commands leading to the flag.
+	DynamicSource DynamicSource
+
+	// Sources, if set, are consulted in order for the values of every flag, with
+	// the first source that has values providing them. CommandLine, EnvVars and
+	// DynamicValues refer to the Args, environment variables and DynamicSource of
+	// the Environment. If Sources is not set, DefaultSources is used. Arguments
+	// are always read from Args, and only read from environment variables if
+	// EnvVars is one of the Sources.
+	Sources []ValueSource
+
 	// Wrap, if set, is called with the context and command that would have
 	// been executed. The no-op implementation is `return cmd.Execute(ctx)`.
 	Wrap func(ctx context.Context, cmd Command) (err error)
 
+	// OnUsageError, if set, is called with each error that caused the command line
+	// to fail to parse, in the order they are printed, before usage is printed.
+	OnUsageError func(err *UsageError)
+
+	// EnvPrefix, if set, binds every flag without a Getenv option to an environment
+	// variable made of the prefix, the names of the commands leading to the flag, and
+	// the name of the flag, all upper cased and joined by underscores, with dashes
+	// replaced by underscores. Global flags use only the prefix and their name. For
+	// example, with the prefix "MYTOOL", the flag --dry-run of the command "repo sync"
+	// is bound to MYTOOL_REPO_SYNC_DRY_RUN. Flags with the NoEnv option are not bound.
+	EnvPrefix string
+
 	// Getenv, if set, is consulted for querying the process environment.
 	// If it is not set, os.Getenv is used.
 	Getenv func(key string) string
 
+	// Prompt, if set, causes the user to be asked for the values of any missing
+	// required flags and arguments when Stdin is a terminal. Prompts are written
+	// to Stderr. Flags and arguments with the Prompt option are always asked for
+	// when Stdin is a terminal.
+	Prompt bool
+
+	// UsageTemplate, if set, is the text/template used to print usage information.
+	// If it is not set, DefaultUsageTemplate is used. The template is executed with
+	// a *UsageData. Invalid templates cause a panic when usage is printed.
+	UsageTemplate string
+
+	// Color, if set, enables styling usage and error output with ANSI escape codes.
+	// It adds a --color=auto|always|never global flag. With auto, colors are used
+	// if Stdout is a terminal and the NO_COLOR environment variable is empty.
+	Color bool
+
+	// Examples, if set, adds an --examples global flag that includes the examples
+	// of every command in the output of --summary.
+	Examples bool
+
+	// UsageWidth, if positive, is the width that descriptions in usage information
+	// are wrapped to. If zero, the width of the terminal for Stdout is used, falling
+	// back to the COLUMNS environment variable. If negative, nothing is wrapped.
+	UsageWidth int
+
+	// CollectDefinitionErrors, if set, causes mistakes in the definitions of flags
+	// and arguments that would otherwise panic, such as defining the same flag
+	// twice, to be collected instead. They are printed with the usage for the
+	// command being run, which is not executed, and Run returns them as an error
+	// that can be inspected with errors.As to find a *DefinitionError. Panics in
+	// Setup after such a mistake are assumed to be caused by it and are ignored.
+	CollectDefinitionErrors bool
+
 	// SuggestionsMinEditDistance defines minimum Levenshtein distance to
 	// display suggestions when a command/subcommand is misspelled.
 	// 0 is the default distance of 2.
 	// A negative value disables suggestions.
 	SuggestionsMinEditDistance int
 
+	// Dir, if set, is the directory that relative paths are resolved against
+	// instead of the working directory of the process. It is used for the files
+	// read by FromFile, and commands can find it with the Dir function.
+	Dir string
+
 	Stdin  io.Reader // Stdin defaults to os.Stdin if unset.
 	Stdout io.Writer // Stdout defaults to os.Stdout if unset.
 	Stderr io.Writer // Stderr defaults to os.Stderr if unset.
+
+	inv *Invocation // if set, commands are parsed but not executed
+}
+
+// Invocation describes what would be executed for some arguments.
+type Invocation struct {
+	// Path contains the name of the binary followed by the names of the
+	// commands that were matched.
+	Path []string
+
+	// Command is the command that would have been executed, after Setup has
+	// been called on it. It is nil if the arguments failed to parse, if usage
+	// information was requested, or if there was no command to execute.
+	Command Command
+
+	// Help is true if usage information or a summary was requested.
+	Help bool
+
+	// Errors contains every error that caused the arguments to fail to parse. If
+	// they did not name a command to execute and Help is false, it contains an
+	// error saying so.
+	Errors []error
 }
@@ -0,0 +1,265 @@
+package clingy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Problem is a mistake in the definition of a tree of commands found by Lint.
+type Problem struct {
+	// Path is the name of the binary followed by the names of the commands
+	// leading to the problem.
+	Path []string
+
+	// Param is the name of the flag or argument with the problem, if any.
+	Param string
+
+	// Rule is the name of the rule that found the problem. Definitions that
+	// would cause a panic when run have the rule "definition".
+	Rule string
+
+	// Message describes the problem.
+	Message string
+}
+
+// String returns a description of the problem including where it was found.
+func (p Problem) String() string {
+	where := strings.Join(p.Path, " ")
+	if p.Param != "" {
+		where += " " + p.Param
+	}
+	return fmt.Sprintf("%s: %s: %s", where, p.Rule, p.Message)
+}
+
+// LintTarget is a command, group, flag or argument checked by a LintRule.
+type LintTarget struct {
+	// Path is the name of the binary followed by the names of the commands
+	// leading to the target, including the target if it is a command or group.
+	Path []string
+
+	// Kind is one of "command", "group", "flag", "global flag" or "argument".
+	Kind string
+
+	// Name is the name of the target.
+	Name string
+
+	// Desc is the description of the target.
+	Desc string
+}
+
+// LintRule checks the targets in a tree of commands, calling report for every
+// target that has a problem.
+type LintRule struct {
+	Name  string
+	Check func(targets []LintTarget, report func(target LintTarget, message string))
+}
+
+var (
+	// LintEmptyDescription reports targets without a description.
+	LintEmptyDescription = LintRule{
+		Name: "empty-description",
+		Check: func(targets []LintTarget, report func(LintTarget, string)) {
+			for _, t := range targets {
+				if strings.TrimSpace(t.Desc) == "" {
+					report(t, "description is empty")
+				}
+			}
+		},
+	}
+
+	// LintTrailingPeriod reports targets whose short description ends with a period.
+	LintTrailingPeriod = LintRule{
+		Name: "trailing-period",
+		Check: func(targets []LintTarget, report func(LintTarget, string)) {
+			for _, t := range targets {
+				if short, _ := parseDesc(t.Desc); strings.HasSuffix(short, ".") {
+					report(t, "description ends with a period")
+				}
+			}
+		},
+	}
+
+	// LintCapitalization reports targets whose description starts with a letter
+	// of a different case than most other descriptions. If there is a tie, lower
+	// case is preferred.
+	LintCapitalization = LintRule{
+		Name: "capitalization",
+		Check: func(targets []LintTarget, report func(LintTarget, string)) {
+			upper := func(desc string) (upper, letter bool) {
+				r, _ := utf8.DecodeRuneInString(strings.TrimSpace(desc))
+				return unicode.IsUpper(r), unicode.IsLetter(r)
+			}
+
+			var lowers, uppers int
+			for _, t := range targets {
+				if up, ok := upper(t.Desc); ok && up {
+					uppers++
+				} else if ok {
+					lowers++
+				}
+			}
+
+			wantUpper := uppers > lowers
+			for _, t := range targets {
+				if up, ok := upper(t.Desc); ok && up != wantUpper {
+					if wantUpper {
+						report(t, "description starts with a lower case letter but most do not")
+					} else {
+						report(t, "description starts with an upper case letter but most do not")
+					}
+				}
+			}
+		},
+	}
+)
+
+// DefaultLintRules are the rules used by Lint when none are provided.
+var DefaultLintRules = []LintRule{
+	LintEmptyDescription,
+	LintTrailingPeriod,
+	LintCapitalization,
+}
+
+// Lint calls fn to create the tree of commands and global flags and calls Setup
+// on the Root and every command in it to find their flags and arguments, without
+// using the Args, Stdin or environment of env. Every definition that would panic
+// when run, such as a flag defined both globally and by a command, or a reused
+// Short name, is reported as a Problem for the parameter, and then every command,
+// group, flag and argument is checked with the rules, or DefaultLintRules if none
+// are provided. Keys listed by the Sources of env that do not match the name of
+// any flag are also reported.
+func Lint(env Environment, fn func(Commands), rules ...LintRule) (problems []Problem) {
+	env.fillDefaults()
+	if len(rules) == 0 {
+		rules = DefaultLintRules
+	}
+
+	var targets []LintTarget
+
+	// define calls cb and reports every definition error it records in defs,
+	// and any other panic, as a problem. It returns false if there were any.
+	define := func(path []string, defs *definitions, cb func()) (ok bool) {
+		n := len(defs.errs)
+		defer func() {
+			rec := recover()
+			for _, err := range defs.errs[n:] {
+				de := err.(*DefinitionError)
+				problems = append(problems, Problem{
+					Path:    path,
+					Param:   de.Param,
+					Rule:    "definition",
+					Message: de.msg,
+				})
+			}
+			if rec != nil && len(defs.errs) == n {
+				problems = append(problems, Problem{
+					Path:    path,
+					Rule:    "definition",
+					Message: fmt.Sprint(rec),
+				})
+			}
+			ok = rec == nil && len(defs.errs) == n
+		}()
+		cb()
+		return true
+	}
+	addParams := func(path []string, kind string, pt *paramsTracker) {
+		pt.params(func(p *param) {
+			if p != nil {
+				targets = append(targets, LintTarget{Path: path, Kind: kind, Name: p.name, Desc: p.desc})
+			}
+		})
+	}
+
+	// newState returns a run state with the global flags defined. it returns
+	// false if defining them failed.
+	root := []string{env.Name}
+	globals := 0
+	newState := func() (st *runState, descs []cmdDesc, ok bool) {
+		st = newRunState(env.Name, []string{}, nil, func(string) string { return "" }, strings.NewReader(""), nil)
+		st.defs.collect = true
+		ok = define(root, st.defs, func() {
+			descs = collectDescs(st.gflags, fn)
+			globals = len(st.gflags.list)
+			env.setupFlags(st)
+		})
+		return st, descs, ok
+	}
+
+	st, descs, ok := newState()
+	if !ok {
+		return problems
+	}
+	addParams(root, "global flag", &paramsTracker{list: st.gflags.list[:globals]})
+
+	setup := func(path []string, cmd Command) {
+		st, _, ok := newState()
+		if !ok || !define(path, st.defs, func() { cmd.Setup(newParams(st.pos, st.flags)) }) {
+			return
+		}
+		addParams(path, "argument", &st.pos.paramsTracker)
+		addParams(path, "flag", &st.flags.paramsTracker)
+	}
+
+	if env.Root != nil {
+		setup(root, env.Root)
+	}
+
+	var walk func(path []string, descs []cmdDesc)
+	walk = func(path []string, descs []cmdDesc) {
+		for _, desc := range descs {
+			dpath := append(path[:len(path):len(path)], desc.name)
+			if desc.hasCmd() {
+				targets = append(targets, LintTarget{Path: dpath, Kind: "command", Name: desc.name, Desc: desc.short})
+				setup(dpath, desc.instance())
+			} else {
+				targets = append(targets, LintTarget{Path: dpath, Kind: "group", Name: desc.name, Desc: desc.short})
+			}
+			walk(dpath, desc.subcmds)
+		}
+	}
+	walk(root, descs)
+
+	// keys of the sources that do not match any flag are likely misspelled.
+	flags := make(map[string]bool)
+	for _, t := range targets {
+		if t.Kind == "flag" || t.Kind == "global flag" {
+			flags[t.Name] = true
+		}
+	}
+	for _, src := range env.Sources {
+		if _, ok := src.(builtinSource); ok {
+			continue
+		}
+		keys, err := src.Keys()
+		if err != nil {
+			problems = append(problems, Problem{
+				Path:    root,
+				Rule:    "source",
+				Message: fmt.Sprintf("unable to list keys of %T: %v", src, err),
+			})
+		}
+		for _, key := range keys {
+			if !flags[key] {
+				problems = append(problems, Problem{
+					Path:    root,
+					Rule:    "source",
+					Message: fmt.Sprintf("key %q of %T does not match any flag", key, src),
+				})
+			}
+		}
+	}
+
+	for _, rule := range rules {
+		rule.Check(targets, func(t LintTarget, message string) {
+			pr := Problem{Path: t.Path, Rule: rule.Name, Message: message}
+			if t.Kind != "command" && t.Kind != "group" {
+				pr.Param = t.Name
+			}
+			problems = append(problems, pr)
+		})
+	}
+	return problems
+}
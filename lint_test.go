@@ -0,0 +1,107 @@
+package clingy_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/assert"
+
+	"github.com/zeebo/clingy"
+)
+
+func lintStrings(problems []clingy.Problem) (out []string) {
+	for _, p := range problems {
+		out = append(out, p.String())
+	}
+	return out
+}
+
+func TestLint(t *testing.T) {
+	flagCommand := func(name, desc string, opts ...clingy.Option) *funcCommand {
+		return &funcCommand{
+			SetupFn: func(params clingy.Parameters) {
+				params.Flag(name, desc, false, append(opts, clingy.Boolean, clingy.Transform(strconv.ParseBool))...)
+				params.Arg("arg", "an argument")
+			},
+			ExecuteFn: func(ctx context.Context) error { return nil },
+		}
+	}
+
+	problems := clingy.Lint(Env("testcommand", nil), func(cmds clingy.Commands) {
+		cmds.Flag("verbose", "prints more", false, clingy.Short('v'))
+		cmds.New("dup", "defines a global flag again", flagCommand("verbose", "again"))
+		cmds.New("short", "reuses a short name", flagCommand("version", "prints the version", clingy.Short('v')))
+		cmds.New("many", "has many mistakes", &funcCommand{
+			SetupFn: func(params clingy.Parameters) {
+				params.Flag("verbose", "again", false)
+				params.Flag("vv", "reuses a short name", false, clingy.Short('v'))
+				params.Flag("bad", "has a bad transform", 0, clingy.Transform(strconv.Atoi), clingy.Transform(strconv.Atoi))
+			},
+			ExecuteFn: func(ctx context.Context) error { return nil },
+		})
+		cmds.Group("grp", "", func() {
+			cmds.New("ok", "a command", flagCommand("force", "forces it"))
+			cmds.New("style", "Ends with a period.", flagCommand("quiet", "Prints less"))
+		})
+	})
+
+	assert.DeepEqual(t, lintStrings(problems), []string{
+		`testcommand dup verbose: definition: parameter already defined with name: "verbose"`,
+		`testcommand short version: definition: parameter already defined with short-name: 'v'`,
+		`testcommand many verbose: definition: parameter already defined with name: "verbose"`,
+		`testcommand many vv: definition: parameter already defined with short-name: 'v'`,
+		`testcommand many bad: definition: parameter has invalid transformation functions: transform: func(string) (int, error) cannot be applied to int`,
+		`testcommand grp: empty-description: description is empty`,
+		`testcommand grp style: trailing-period: description ends with a period`,
+		`testcommand grp style: capitalization: description starts with an upper case letter but most do not`,
+		`testcommand grp style quiet: capitalization: description starts with an upper case letter but most do not`,
+	})
+	assert.Equal(t, problems[8].Param, "quiet")
+	assert.DeepEqual(t, problems[8].Path, []string{"testcommand", "grp", "style"})
+}
+
+func TestLint_Rules(t *testing.T) {
+	noShort := clingy.LintRule{
+		Name: "no-short",
+		Check: func(targets []clingy.LintTarget, report func(clingy.LintTarget, string)) {
+			for _, t := range targets {
+				if len(t.Name) < 3 {
+					report(t, "name is too short")
+				}
+			}
+		},
+	}
+
+	problems := clingy.Lint(Env("testcommand", nil), func(cmds clingy.Commands) {
+		cmds.New("ls", "", printCommand("ls"))
+		cmds.New("list", "Lists things.", printCommand("list"))
+	}, noShort)
+	assert.DeepEqual(t, lintStrings(problems), []string{
+		`testcommand ls: no-short: name is too short`,
+	})
+}
+
+func TestLint_Globals(t *testing.T) {
+	problems := clingy.Lint(Env("testcommand", nil), func(cmds clingy.Commands) {
+		cmds.Flag("help", "conflicts with the built in flag", false)
+		cmds.New("cmd", "a command", printCommand("cmd"))
+	})
+	assert.Equal(t, len(problems), 1)
+	assert.That(t, strings.Contains(problems[0].String(), `testcommand help: definition: parameter already defined with name: "help"`))
+}
+
+func TestLint_Sources(t *testing.T) {
+	env := Env("testcommand", nil)
+	env.Sources = []clingy.ValueSource{clingy.CommandLine, mapSource{"name": "x", "nmae": "y"}}
+	problems := clingy.Lint(env, func(cmds clingy.Commands) {
+		cmds.New("cmd", "a command", &funcCommand{
+			SetupFn:   func(params clingy.Parameters) { params.Flag("name", "a name", "") },
+			ExecuteFn: func(ctx context.Context) error { return nil },
+		})
+	})
+	assert.DeepEqual(t, lintStrings(problems), []string{
+		`testcommand: source: key "nmae" of clingy_test.mapSource does not match any flag`,
+	})
+}
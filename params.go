@@ -9,15 +9,22 @@ import (
 //
 
 type paramOpts struct {
-	opt    bool
-	rep    bool
-	short  byte
-	adv    bool
-	hidden bool
-	bstyle bool
-	getenv string
-	typ    string
-	fns    []interface{}
+	opt     bool
+	rep     bool
+	short   byte
+	adv     bool
+	hidden  bool
+	bstyle  bool
+	file    bool
+	prompt  bool
+	secret  bool
+	noenv   bool
+	builtin bool // builtin flags are always read from the command line
+	enum    []string
+	getenv  string
+	envsep  string
+	typ     string
+	fns     []interface{}
 }
 
 type param struct {
@@ -81,14 +88,14 @@ func (p *params) Arg(name, desc string, options ...Option) (val interface{}) {
 
 func (p *params) Flag(name, desc string, def interface{}, options ...Option) (val interface{}) {
 	if p.pos {
-		panic("must perform all Flag/Break calls before any Arg calls")
+		p.pf.pm.defs.fail(name, "must perform all Flag/Break calls before any Arg calls")
 	}
 	return p.pf.Flag(name, desc, def, options...)
 }
 
 func (p *params) Break() {
 	if p.pos {
-		panic("must perform all Flag/Break calls before any Arg calls")
+		p.pf.pm.defs.fail("", "must perform all Flag/Break calls before any Arg calls")
 	}
 	p.pf.Break()
 }
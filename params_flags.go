@@ -1,17 +1,29 @@
 package clingy
 
-import (
-	"fmt"
-
-	"github.com/zeebo/errs/v2"
-)
+import "strings"
 
 type paramsFlags struct {
 	paramsTracker
-	pm *paramsMaker
-	ah *argsHandler
+	pm     *paramsMaker
+	ah     *argsHandler
+	envKey string   // if set, the prefix of environment variables flags are bound to
+	path   []string // the path of commands passed to the dynamic source
+	global bool     // if the flags are global flags
+
+	results map[string]flagResult // if set, where the result of every flag is recorded
+	prev    map[string]flagResult // results recorded by a previous run to reuse
+}
+
+// flagResult is the outcome of parsing a flag.
+type flagResult struct {
+	val  interface{}
+	err  error
+	args []int // the indexes of the arguments consumed for the flag
 }
 
+// consumedFrom returns true if any argument at or after the index was consumed.
+func (fr flagResult) consumedFrom(i int) bool { return consumedFrom(fr.args, i) }
+
 func newParamsFlags(ps *paramsMaker, ah *argsHandler) *paramsFlags {
 	return &paramsFlags{
 		pm: ps,
@@ -20,19 +32,50 @@ func newParamsFlags(ps *paramsMaker, ah *argsHandler) *paramsFlags {
 }
 
 func (pf *paramsFlags) Flag(name, desc string, def interface{}, options ...Option) (val interface{}) {
-	p := pf.pm.newParam(name, desc, def, options...)
+	p, ok := pf.pm.newParam(name, desc, def, options...)
+	if !ok {
+		if p == nil {
+			return nil
+		}
+		return p.zero()
+	}
 	pf.include(p)
 
+	if pf.envKey != "" && p.getenv == "" && !p.noenv {
+		p.getenv = envKey(pf.envKey, name)
+	}
+
 	if p.opt && p.def == Required {
-		panic(fmt.Sprintf("optional flag with Required default value: %q", name))
+		pf.pm.defs.fail(name, "optional flag with Required default value: %q", name)
+		return p.zero()
 	}
 
+	if res, ok := pf.prev[name]; ok {
+		pf.ah.consume(res.args)
+		p.err = res.err
+		return res.val
+	}
+
+	n := len(pf.ah.consumed)
+	val = pf.parse(p)
+	if pf.results != nil {
+		args := append([]int(nil), pf.ah.consumed[n:]...)
+		pf.results[name] = flagResult{val: val, err: p.err, args: args}
+	}
+	return val
+}
+
+// parse returns the value of the flag, recording any error parsing it.
+func (pf *paramsFlags) parse(p *param) (val interface{}) {
 	val, p.err = pf.getValue(p)
+	if p.err == nil && val == nil {
+		val, p.err = pf.ah.PromptValue(p, p.def == Required)
+	}
 	if p.err != nil {
 		return p.zero()
 	} else if val == nil {
 		if p.def == Required {
-			p.err = errs.Errorf("%s: required flag missing", name)
+			p.err = missingRequired(p.name, "%s: required flag missing", p.name)
 			return p.zero()
 		} else if p.def == nil {
 			return p.zero()
@@ -40,19 +83,14 @@ func (pf *paramsFlags) Flag(name, desc string, def interface{}, options ...Optio
 		return p.def
 	}
 
-	val, p.err = transformParam(p, val)
+	val, p.err = transformParam(pf.ah, p, val)
 	return val
 }
 
 func (pf *paramsFlags) getValue(p *param) (val interface{}, err error) {
-	vals, err := pf.ah.ConsumeFlag(p.name, p.bstyle, p.getenv)
+	vals, err := pf.ah.lookupFlag(p, DynamicFlag{Path: pf.path, Name: p.name, Global: pf.global})
 	if err != nil {
 		return nil, err
-	} else if vals == nil && p.short != 0 {
-		vals, err = pf.ah.ConsumeFlag(string(p.short), p.bstyle, p.getenv)
-		if err != nil {
-			return nil, err
-		}
 	}
 	if p.rep {
 		return vals, nil
@@ -62,3 +100,10 @@ func (pf *paramsFlags) getValue(p *param) (val interface{}, err error) {
 		return vals[0], nil
 	}
 }
+
+// envKey joins the parts into the name of an environment variable, upper casing
+// them and replacing dashes with underscores.
+func envKey(parts ...string) string {
+	key := strings.Join(parts, "_")
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
@@ -10,6 +10,7 @@ func (c *charSet) Has(x byte) bool { return c[x/32]&(1<<(x%32)) != 0 }
 type paramsMaker struct {
 	set    map[string]*param
 	shorts charSet
+	defs   *definitions
 }
 
 func newParamsMaker() *paramsMaker {
@@ -18,22 +19,59 @@ func newParamsMaker() *paramsMaker {
 	}
 }
 
-func (ps *paramsMaker) newParam(name, desc string, def interface{}, options ...Option) *param {
-	p := &param{name: name, def: def, desc: desc}
+// newParam creates the parameter. If it could not be created because of a definition
+// error, ok is false and the parameter is nil if its type could not be determined.
+func (ps *paramsMaker) newParam(name, desc string, def interface{}, options ...Option) (p *param, ok bool) {
+	p = &param{name: name, def: def, desc: desc}
 	for _, opt := range options {
 		opt.do(&p.paramOpts)
 	}
-	if _, ok := ps.set[name]; ok {
-		panic(fmt.Sprintf("parameter already defined with name: %q", name))
-	} else if p.short != 0 && ps.shorts.Has(p.short) {
-		panic(fmt.Sprintf("parameter already defined with short-name: %q", p.short))
-	}
 	var err error
 	p.typ, err = checkFns(p.fns)
 	if err != nil {
-		panic(fmt.Sprintf("parameter has invalid transformation functions: %v", err))
+		ps.defs.fail(name, "parameter has invalid transformation functions: %v", err)
+		return nil, false
+	}
+	if _, ok := ps.set[name]; ok {
+		ps.defs.fail(name, "parameter already defined with name: %q", name)
+		return p, false
+	} else if p.short != 0 && ps.shorts.Has(p.short) {
+		ps.defs.fail(name, "parameter already defined with short-name: %q", p.short)
+		return p, false
 	}
 	ps.set[name] = p
 	ps.shorts.Set(p.short)
-	return p
+	return p, true
+}
+
+// definitions records mistakes in the definitions of parameters. Unless they are
+// being collected, they cause a panic.
+type definitions struct {
+	collect bool
+	errs    []error
+}
+
+func (d *definitions) fail(name, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if d == nil || !d.collect {
+		panic(msg)
+	}
+	d.errs = append(d.errs, &DefinitionError{Param: name, msg: msg})
+}
+
+// guard calls fn. If definition errors are being collected and fn panics after
+// recording one, the panic is ignored because it is most likely caused by the
+// recorded error, such as a type assertion on the nil value returned for it.
+func (d *definitions) guard(fn func()) {
+	if d == nil || !d.collect {
+		fn()
+		return
+	}
+	n := len(d.errs)
+	defer func() {
+		if len(d.errs) > n {
+			_ = recover()
+		}
+	}()
+	fn()
 }
@@ -1,11 +1,5 @@
 package clingy
 
-import (
-	"fmt"
-
-	"github.com/zeebo/errs/v2"
-)
-
 type paramsPos struct {
 	paramsTracker
 	pm  *paramsMaker
@@ -22,38 +16,59 @@ func newParamsPositional(pm *paramsMaker, ah *argsHandler) *paramsPos {
 }
 
 func (pp *paramsPos) Arg(name, desc string, options ...Option) (val interface{}) {
-	p := pp.pm.newParam(name, desc, nil, options...)
+	p, ok := pp.pm.newParam(name, desc, nil, options...)
+	if !ok {
+		if p == nil {
+			return nil
+		}
+		return p.zero()
+	}
 	pp.include(p)
 
 	// check for repeated/optional consistency
 	if pp.opt && !(p.opt || p.rep) {
-		panic(fmt.Sprintf("required argument after optional arguments: %q", name))
+		pp.pm.defs.fail(name, "required argument after optional arguments: %q", name)
+		return p.zero()
 	}
 	if pp.rep {
-		panic(fmt.Sprintf("argument after repeated argument: %q", name))
+		pp.pm.defs.fail(name, "argument after repeated argument: %q", name)
+		return p.zero()
 	}
 	pp.opt = pp.opt || p.opt
 	pp.rep = pp.rep || p.rep
 
 	if p.rep {
-		val, p.err = pp.ah.ConsumeArgs()
+		var vals []string
+		vals, p.err = pp.ah.ConsumeArgs()
 		if p.err != nil {
 			return p.zero()
+		} else if len(vals) == 0 {
+			vals = pp.ah.lookupArgEnv(p.getenv, p.envsep)
 		}
+		val = vals
 	} else {
 		var ok bool
 		val, ok, p.err = pp.ah.ConsumeArg()
+		if p.err == nil && !ok {
+			if vals := pp.ah.lookupArgEnv(p.getenv, ""); len(vals) > 0 {
+				val, ok = vals[0], true
+			}
+		}
+		if p.err == nil && !ok {
+			val, p.err = pp.ah.PromptValue(p, !p.opt)
+			ok = val != nil
+		}
 		if p.err != nil {
 			return p.zero()
 		} else if !ok {
 			if !p.opt {
-				p.err = errs.Errorf("%s: required argument missing", name)
+				p.err = missingRequired(name, "%s: required argument missing", name)
 				return p.zero()
 			}
 			return p.zero()
 		}
 	}
 
-	val, p.err = transformParam(p, val)
+	val, p.err = transformParam(pp.ah, p, val)
 	return val
 }
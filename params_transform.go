@@ -2,22 +2,49 @@ package clingy
 
 import (
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/zeebo/errs/v2"
 )
 
-func transformParam(arg *param, val interface{}) (_ interface{}, err error) {
-	call := callOne
-	if arg.rep {
-		call = callMany
+func transformParam(ah *argsHandler, arg *param, val interface{}) (_ interface{}, err error) {
+	raw := val
+	if arg.secret {
+		defer func() {
+			if err != nil {
+				err = redactError(err)
+			}
+		}()
+	}
+
+	if arg.file {
+		// nothing is read when only printing usage because reading stdin can block.
+		if ah.noRead {
+			return arg.zero(), nil
+		}
+		val, err = readValues(ah, arg, val)
+		if err != nil {
+			return arg.zero(), err
+		}
+	}
+
+	if len(arg.enum) > 0 {
+		if err := checkEnum(arg, val); err != nil {
+			return arg.zero(), err
+		}
 	}
 
 	rval := reflect.ValueOf(val)
 	for _, fn := range arg.fns {
-		rval, err = call(rval, reflect.ValueOf(fn))
+		var idx int
+		if arg.rep {
+			rval, idx, err = callMany(rval, reflect.ValueOf(fn))
+		} else {
+			rval, err = callOne(rval, reflect.ValueOf(fn))
+		}
 		if err != nil {
-			return arg.zero(), err
+			return arg.zero(), invalidValue(arg.name, rawValue(raw, idx), err, "%s", err)
 		}
 	}
 
@@ -27,19 +54,67 @@ func transformParam(arg *param, val interface{}) (_ interface{}, err error) {
 	return rval.Interface(), nil
 }
 
-func callMany(rval, rfn reflect.Value) (reflect.Value, error) {
+func readValues(ah *argsHandler, arg *param, val interface{}) (interface{}, error) {
+	switch val := val.(type) {
+	case string:
+		return ah.ReadValue(arg.name, val)
+	case []string:
+		out := make([]string, len(val))
+		for i, v := range val {
+			data, err := ah.ReadValue(arg.name, v)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = data
+		}
+		return out, nil
+	default:
+		return val, nil
+	}
+}
+
+func checkEnum(arg *param, val interface{}) error {
+	vals, _ := val.([]string)
+	if s, ok := val.(string); ok {
+		vals = []string{s}
+	}
+next:
+	for _, v := range vals {
+		for _, choice := range arg.enum {
+			if v == choice {
+				continue next
+			}
+		}
+		return invalidValue(arg.name, v, nil, "%s: invalid value %q: must be one of %s", arg.name, v, strings.Join(arg.enum, ", "))
+	}
+	return nil
+}
+
+func rawValue(raw interface{}, idx int) string {
+	switch raw := raw.(type) {
+	case string:
+		return raw
+	case []string:
+		if idx < len(raw) {
+			return raw[idx]
+		}
+	}
+	return ""
+}
+
+func callMany(rval, rfn reflect.Value) (reflect.Value, int, error) {
 	if rval.IsNil() {
-		return reflect.Zero(reflect.SliceOf(rfn.Type().Out(0))), nil
+		return reflect.Zero(reflect.SliceOf(rfn.Type().Out(0))), 0, nil
 	}
 	out := reflect.MakeSlice(reflect.SliceOf(rfn.Type().Out(0)), rval.Len(), rval.Len())
 	for i := 0; i < rval.Len(); i++ {
 		result, err := callOne(rval.Index(i), rfn)
 		if err != nil {
-			return reflect.Value{}, err
+			return reflect.Value{}, i, err
 		}
 		out.Index(i).Set(result)
 	}
-	return out, nil
+	return out, 0, nil
 }
 
 func callOne(rval, rfn reflect.Value) (reflect.Value, error) {
@@ -0,0 +1,115 @@
+package clingy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// prompter holds the state required to interactively ask for parameter values.
+type prompter struct {
+	all bool          // prompt for every missing required parameter
+	in  *bufio.Reader // buffered stdin used for reading responses
+	raw io.Reader     // unbuffered stdin used for controlling echo
+	out io.Writer     // where the prompts are written
+}
+
+// EnablePrompt causes missing parameters to be asked for on stdin with prompts
+// written to w. If all is true, every missing required parameter is asked for.
+// Otherwise, only parameters with the Prompt option are.
+func (ah *argsHandler) EnablePrompt(all bool, w io.Writer) {
+	in := bufio.NewReader(ah.stdin)
+	ah.prompt = &prompter{all: all, in: in, raw: ah.stdin, out: w}
+	ah.stdin = in
+}
+
+// PromptValue asks for the value of the parameter if prompting is enabled for
+// it. It returns nil if no value was provided.
+func (ah *argsHandler) PromptValue(p *param, required bool) (interface{}, error) {
+	pr := ah.prompt
+	if pr == nil || !(p.prompt || (required && pr.all)) || ah.stdinBy != "" {
+		return nil, nil
+	}
+
+	label := p.desc
+	if label == "" {
+		label = p.name
+	}
+
+	var val string
+	var ok bool
+	var err error
+	if len(p.enum) > 0 {
+		val, ok, err = pr.choose(label, p.enum)
+	} else {
+		val, ok, err = pr.ask(label, p.secret)
+	}
+	if err != nil {
+		return nil, invalidValue(p.name, "", err, "%s: unable to read input: %v", p.name, err)
+	} else if !ok {
+		return nil, nil
+	} else if p.rep {
+		return []string{val}, nil
+	}
+	return val, nil
+}
+
+func (pr *prompter) ask(label string, secret bool) (string, bool, error) {
+	if secret {
+		restore, ok := disableEcho(pr.raw)
+		if !ok {
+			return "", false, nil
+		}
+		defer func() {
+			restore()
+			fmt.Fprintln(pr.out)
+		}()
+	}
+
+	for {
+		fmt.Fprintf(pr.out, "%s: ", label)
+		line, ok, err := pr.readLine()
+		if err != nil || !ok {
+			return "", false, err
+		} else if line != "" {
+			return line, true, nil
+		}
+	}
+}
+
+func (pr *prompter) choose(label string, choices []string) (string, bool, error) {
+	fmt.Fprintf(pr.out, "%s:\n", label)
+	for i, choice := range choices {
+		fmt.Fprintf(pr.out, "\t%d) %s\n", i+1, choice)
+	}
+
+	for {
+		fmt.Fprintf(pr.out, "Select [1-%d]: ", len(choices))
+		line, ok, err := pr.readLine()
+		if err != nil || !ok {
+			return "", false, err
+		}
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(choices) {
+			return choices[n-1], true, nil
+		}
+		for _, choice := range choices {
+			if line == choice {
+				return choice, true, nil
+			}
+		}
+	}
+}
+
+func (pr *prompter) readLine() (string, bool, error) {
+	line, err := pr.in.ReadString('\n')
+	if err == io.EOF {
+		if line == "" {
+			return "", false, nil
+		}
+	} else if err != nil {
+		return "", false, err
+	}
+	return strings.TrimRight(line, "\r\n"), true, nil
+}
@@ -0,0 +1,57 @@
+package clingy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+type promptGlobalCmd struct {
+	rest string
+}
+
+func (c *promptGlobalCmd) Setup(params Parameters) {}
+
+func (c *promptGlobalCmd) Execute(ctx context.Context) (err error) {
+	c.rest, err = bufio.NewReader(Stdin(ctx)).ReadString('\n')
+	return err
+}
+
+func TestPrompt_GlobalFlagAndStdin(t *testing.T) {
+	ptm, pts := openPty(t)
+
+	// raw mode so that both lines are delivered by a single read and any input
+	// past the prompted line must be buffered.
+	restore, ok := makeRaw(pts)
+	if !ok {
+		t.Skip("unable to put pseudo terminal into raw mode")
+	}
+	defer restore()
+
+	_, err := ptm.Write([]byte("value\nrest\n"))
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	var global string
+	cmd := new(promptGlobalCmd)
+
+	ok, err = Environment{
+		Name:   "testcommand",
+		Args:   []string{"cmd"},
+		Stdin:  pts,
+		Stdout: &out,
+		Stderr: &out,
+		Prompt: true,
+	}.Run(context.Background(), func(cmds Commands) {
+		global = cmds.Flag("global", "global value", Required).(string)
+		cmds.New("cmd", "a command", cmd)
+	})
+	assert.NoError(t, err)
+	assert.That(t, ok)
+	assert.Equal(t, global, "value")
+	assert.Equal(t, cmd.rest, "rest\n")
+	assert.Equal(t, out.String(), "global value: ")
+}
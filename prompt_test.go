@@ -0,0 +1,33 @@
+package clingy
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestPrompt(t *testing.T) {
+	var (
+		out   bytes.Buffer
+		pm    = newParamsMaker()
+		ah    = newArgsHandler(nil, nil, nil, strings.NewReader("\nvalue\nfoo\n2\n"))
+		pos   = newParamsPositional(pm, ah)
+		flags = newParamsFlags(pm, ah)
+	)
+	ah.EnablePrompt(true, &out)
+
+	assert.Equal(t, "value", flags.Flag("req", "required value", Required).(string))
+	assert.Equal(t, "def", flags.Flag("opt", "optional value", "def").(string))
+	assert.Equal(t, "b", flags.Flag("enum", "enum value", Required, Enum("a", "b", "c")).(string))
+	assert.Equal(t, 0, pos.Arg("num", "", Transform(strconv.Atoi)).(int))
+	assert.Nil(t, pos.Arg("missing", "", Optional, Prompt).(*string))
+
+	assert.Equal(t, out.String(), ""+
+		"required value: required value: "+
+		"enum value:\n\t1) a\n\t2) b\n\t3) c\nSelect [1-3]: Select [1-3]: "+
+		"num: "+
+		"missing: ")
+}
@@ -0,0 +1,276 @@
+package clingy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// REPL repeatedly reads a line from the Stdin of the Environment, splits it into
+// arguments like a shell would, and calls Run with those arguments and fn. Because
+// fn is called for every line, commands created inside of it are fresh for every
+// line. A prompt is written to Stderr before each line. If Stdin is a terminal,
+// previous lines can be recalled with the up and down arrow keys and the names of
+// commands can be completed with the tab key.
+//
+// REPL returns when Stdin has no more lines, the line "exit" is read, or the context
+// is canceled, including while waiting for a line. Errors returned by commands are
+// printed to Stderr.
+func REPL(ctx context.Context, env Environment, fn func(Commands)) error {
+	env.fillDefaults()
+
+	tree := collectDescs(newParamsFlags(newParamsMaker(), newArgsHandler(nil, nil, nil, nil)), fn)
+	lr := newLineReader(env.Stdin, env.Stderr, func(line string) (string, []string) {
+		return completeCommand(tree, line)
+	})
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line, err := lr.ReadLine(ctx, env.Name+"> ")
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		args, err := splitArgs(line)
+		if err != nil {
+			fmt.Fprintf(env.Stderr, "%s: %v\n", env.Name, err)
+			continue
+		} else if len(args) == 0 {
+			continue
+		} else if len(args) == 1 && args[0] == "exit" {
+			return nil
+		}
+
+		run := env
+		run.Args = args
+		run.Stdin = lr.Stdin()
+		if _, err := run.Run(ctx, fn); err != nil {
+			fmt.Fprintf(env.Stderr, "%s: %v\n", env.Name, err)
+		}
+	}
+}
+
+// completeCommand returns the partially typed last word of the line and the
+// names of the commands it could be completed to.
+func completeCommand(descs []cmdDesc, line string) (word string, names []string) {
+	words := strings.Fields(line)
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		word, words = words[len(words)-1], words[:len(words)-1]
+	}
+
+next:
+	for _, w := range words {
+		for _, desc := range descs {
+			if desc.name == w {
+				descs = desc.subcmds
+				continue next
+			}
+		}
+	}
+
+	for _, desc := range descs {
+		if strings.HasPrefix(desc.name, word) {
+			names = append(names, desc.name)
+		}
+	}
+	return word, names
+}
+
+// lineReader reads lines, allowing them to be edited if it is reading from
+// a terminal.
+type lineReader struct {
+	in       io.Reader
+	buf      *bufio.Reader
+	out      io.Writer
+	complete func(line string) (word string, cands []string)
+	history  []string
+}
+
+func newLineReader(in io.Reader, out io.Writer, complete func(string) (string, []string)) *lineReader {
+	return &lineReader{
+		in:       in,
+		buf:      bufio.NewReader(in),
+		out:      out,
+		complete: complete,
+	}
+}
+
+// Stdin returns the reader that should be used by anything else reading from
+// the input so that no buffered data is lost.
+func (lr *lineReader) Stdin() io.Reader {
+	if isTerminal(lr.in) {
+		return lr.in
+	}
+	return lr.buf
+}
+
+// ReadLine writes the prompt and returns the next line. It returns io.EOF if
+// there are no more lines, and the error of the context if it is canceled
+// before a line is read. A read that is still blocked when the context is
+// canceled is abandoned, and the lineReader must not be used afterwards.
+func (lr *lineReader) ReadLine(ctx context.Context, prompt string) (string, error) {
+	restore, raw := makeRaw(lr.in)
+	if raw {
+		defer restore()
+	}
+
+	type result struct {
+		line string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		line, err := lr.readLine(prompt, raw)
+		done <- result{line: line, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.line, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// readLine writes the prompt and returns the next line, allowing it to be
+// edited if the input is a terminal in raw mode.
+func (lr *lineReader) readLine(prompt string, raw bool) (string, error) {
+	if !raw {
+		fmt.Fprint(lr.out, prompt)
+		line, err := lr.buf.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		} else if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lr.addHistory(line)
+		return line, nil
+	}
+
+	var line []byte
+	hist := len(lr.history)
+	redraw := func() { fmt.Fprintf(lr.out, "\r\x1b[K%s%s", prompt, line) }
+	redraw()
+
+	var b [1]byte
+	readByte := func() (byte, error) {
+		_, err := io.ReadFull(lr.in, b[:])
+		return b[0], err
+	}
+
+	for {
+		c, err := readByte()
+		if err != nil {
+			return "", err
+		}
+
+		switch c {
+		case '\r', '\n':
+			fmt.Fprint(lr.out, "\r\n")
+			lr.addHistory(string(line))
+			return string(line), nil
+
+		case 3: // ctrl-c abandons the line
+			fmt.Fprint(lr.out, "^C\r\n")
+			line, hist = nil, len(lr.history)
+			redraw()
+
+		case 4: // ctrl-d on an empty line ends input
+			if len(line) == 0 {
+				fmt.Fprint(lr.out, "\r\n")
+				return "", io.EOF
+			}
+
+		case 8, 127: // backspace
+			if len(line) > 0 {
+				_, size := utf8.DecodeLastRune(line)
+				line = line[:len(line)-size]
+				redraw()
+			}
+
+		case '\t':
+			word, cands := lr.complete(string(line))
+			if prefix := commonPrefix(cands); len(prefix) > len(word) {
+				line = append(line, prefix[len(word):]...)
+				if len(cands) == 1 {
+					line = append(line, ' ')
+				}
+			} else if len(cands) > 1 {
+				fmt.Fprintf(lr.out, "\r\n%s\r\n", strings.Join(cands, "  "))
+			}
+			redraw()
+
+		case 0x1b: // escape sequences, of which only the arrow keys are handled
+			if c, err = readByte(); err != nil {
+				return "", err
+			}
+			switch c {
+			case 'O': // single shift sequences have one more byte
+				if c, err = readByte(); err != nil {
+					return "", err
+				}
+			case '[': // control sequences end with a byte in 0x40-0x7e
+				for {
+					if c, err = readByte(); err != nil {
+						return "", err
+					} else if c >= 0x40 && c <= 0x7e {
+						break
+					}
+				}
+			default:
+				continue
+			}
+			switch {
+			case c == 'A' && hist > 0:
+				hist--
+			case c == 'B' && hist < len(lr.history):
+				hist++
+			default:
+				continue
+			}
+			line = nil
+			if hist < len(lr.history) {
+				line = append(line, lr.history[hist]...)
+			}
+			redraw()
+
+		default:
+			if c >= 0x20 {
+				line = append(line, c)
+				redraw()
+			}
+		}
+	}
+}
+
+func (lr *lineReader) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(lr.history); n > 0 && lr.history[n-1] == line {
+		return
+	}
+	lr.history = append(lr.history, line)
+}
+
+func commonPrefix(strs []string) string {
+	if len(strs) == 0 {
+		return ""
+	}
+	prefix := strs[0]
+	for _, s := range strs[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
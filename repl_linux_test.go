@@ -0,0 +1,27 @@
+package clingy
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestLineReader_EscapeSequences(t *testing.T) {
+	ptm, pts := openPty(t)
+
+	// delete, home and end have sequences longer than the arrow keys, and the
+	// up arrow can be sent as a single shift sequence.
+	_, err := ptm.Write([]byte("ab\x1b[3~c\x1b[H\x1b[1;5F\r\x1bOA\r"))
+	assert.NoError(t, err)
+
+	lr := newLineReader(pts, io.Discard, func(string) (string, []string) { return "", nil })
+	line, err := lr.ReadLine(context.Background(), "> ")
+	assert.NoError(t, err)
+	assert.Equal(t, line, "abc")
+
+	line, err = lr.ReadLine(context.Background(), "> ")
+	assert.NoError(t, err)
+	assert.Equal(t, line, "abc")
+}
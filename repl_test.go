@@ -0,0 +1,106 @@
+package clingy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+type replCommand struct {
+	name string
+	args []string
+	out  *[]string
+}
+
+func (r *replCommand) Setup(params Parameters) {
+	r.args = params.Arg("args", "", Repeated).([]string)
+}
+
+func (r *replCommand) Execute(ctx context.Context) error {
+	*r.out = append(*r.out, r.name+" "+strings.Join(r.args, " "))
+	return nil
+}
+
+func TestREPL(t *testing.T) {
+	var out []string
+	fn := func(cmds Commands) {
+		cmds.New("echo", "", &replCommand{name: "echo", out: &out})
+		cmds.Group("grp", "", func() {
+			cmds.New("cmd", "", &replCommand{name: "cmd", out: &out})
+		})
+	}
+
+	var stderr bytes.Buffer
+	err := REPL(context.Background(), Environment{
+		Name:   "test",
+		Stdin:  strings.NewReader("echo a 'b c'\n\ngrp cmd d\nexit\necho e\n"),
+		Stdout: new(bytes.Buffer),
+		Stderr: &stderr,
+		Getenv: func(string) string { return "" },
+	}, fn)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, out, []string{"echo a b c", "cmd d"})
+	assert.Equal(t, stderr.String(), "test> test> test> test> ")
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (fn writerFunc) Write(p []byte) (int, error) { return fn(p) }
+
+func TestREPL_Cancel(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer func() { _ = pw.Close() }()
+
+	prompted := make(chan struct{})
+	var once sync.Once
+	stderr := writerFunc(func(p []byte) (int, error) {
+		once.Do(func() { close(prompted) })
+		return len(p), nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errch := make(chan error, 1)
+	go func() {
+		errch <- REPL(ctx, Environment{
+			Name:   "test",
+			Stdin:  pr,
+			Stdout: new(bytes.Buffer),
+			Stderr: stderr,
+			Getenv: func(string) string { return "" },
+		}, nil)
+	}()
+
+	// once prompted, the REPL is blocked reading a line, which canceling interrupts.
+	<-prompted
+	cancel()
+	assert.Equal(t, <-errch, context.Canceled)
+}
+
+func TestREPL_Complete(t *testing.T) {
+	tree := []cmdDesc{
+		{name: "echo"},
+		{name: "grp", subcmds: []cmdDesc{{name: "cmd1"}, {name: "cmd2"}}},
+		{name: "grp2"},
+	}
+
+	check := func(line, word string, names ...string) {
+		t.Helper()
+		gotWord, gotNames := completeCommand(tree, line)
+		assert.Equal(t, gotWord, word)
+		assert.DeepEqual(t, gotNames, names)
+	}
+
+	check("e", "e", "echo")
+	check("gr", "gr", "grp", "grp2")
+	check("grp ", "", "cmd1", "cmd2")
+	check("grp --flag c", "c", "cmd1", "cmd2")
+	check("x", "x")
+
+	assert.Equal(t, commonPrefix([]string{"cmd1", "cmd2"}), "cmd")
+	assert.Equal(t, commonPrefix(nil), "")
+}
@@ -2,8 +2,11 @@ package clingy
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/zeebo/errs/v2"
 )
@@ -34,17 +37,121 @@ func (env *Environment) fillDefaults() {
 // was successful. The error is the returned error from any executed command.
 func (env Environment) Run(ctx context.Context, fn func(Commands)) (bool, error) {
 	env.fillDefaults()
-	st := newRunState(env.Name, env.Args, env.Dynamic, env.Getenv)
-	descs := collectDescs(st.gflags, fn)
-	st.setupFlags()
+	return env.run(ctx, fn)
+}
+
+// Parse calls the fn to create the tree of commands and global flags and parses
+// the arguments exactly like Run, including printing usage information, except
+// that it does not execute the command. It returns a description of what would
+// have been executed. The error is non-nil if the arguments failed to parse, or
+// if they do not name a command to execute and usage was not requested, and it
+// contains the same errors as the Invocation.
+func (env Environment) Parse(ctx context.Context, fn func(Commands)) (*Invocation, error) {
+	env.fillDefaults()
+	env.inv = new(Invocation)
+	_, _ = env.run(ctx, fn)
+	if env.inv.Command == nil && !env.inv.Help && len(env.inv.Errors) == 0 {
+		env.inv.Errors = append(env.inv.Errors, errs.Errorf("no command to execute: %q",
+			strings.Join(env.inv.Path, " ")))
+	}
+	return env.inv, errs.Combine(env.inv.Errors...)
+}
+
+// CheckExamples parses every example of every command in the tree created by fn
+// using Parse, returning an error describing every example that fails to parse.
+// The examples are parsed with an empty stdin and environment, and without
+// prompting. The Args, Stdin, Stdout, Getenv and Prompt of the Environment are
+// ignored.
+func (env Environment) CheckExamples(ctx context.Context, fn func(Commands)) error {
+	env.Stdin = strings.NewReader("")
+	env.Stdout = io.Discard
+	env.Getenv = func(string) string { return "" }
+	env.Prompt = false
+	env.fillDefaults()
+
+	var group errs.Group
+	var walk func(path []string, descs []cmdDesc)
+	walk = func(path []string, descs []cmdDesc) {
+		for _, desc := range descs {
+			dpath := append(path[:len(path):len(path)], desc.name)
+			for _, ex := range desc.examples {
+				args, err := splitArgs(ex.args)
+				if err == nil {
+					env.Args = append(dpath[1:len(dpath):len(dpath)], args...)
+					_, err = env.Parse(ctx, fn)
+				}
+				if err != nil {
+					group.Add(errs.Errorf("example %q: %w", exampleLine(dpath, ex), err))
+				}
+			}
+			walk(dpath, desc.subcmds)
+		}
+	}
+	walk([]string{env.Name}, collectDescs(newParamsFlags(newParamsMaker(), newArgsHandler(nil, nil, nil, nil)), fn))
+
+	return group.Err()
+}
+
+func (env *Environment) run(ctx context.Context, fn func(Commands)) (bool, error) {
+	st := newRunState(env.Name, env.Args, env.dynamicSource(), env.Getenv, env.Stdin, env.Sources)
+	executed, err := env.runWith(ctx, st, fn)
+
+	// global flags were taken from the arguments passed through to the command,
+	// so fn has to be called again to parse them without those arguments. the
+	// results of every other global flag are reused rather than parsed again.
+	if st.reparse {
+		next := newRunState(env.Name, env.Args, env.dynamicSource(), env.Getenv, st.ah.stdin, env.Sources)
+		next.reuse(st)
+		executed, err = env.runWith(ctx, next, fn)
+	}
+	return executed, err
+}
+
+func (env *Environment) runWith(ctx context.Context, st *runState, fn func(Commands)) (bool, error) {
+	st.ah.dir = env.Dir
+	st.defs.collect = env.CollectDefinitionErrors
+	st.gflags.envKey = env.EnvPrefix
+
+	// the builtin global flags are defined first so that prompting can be enabled
+	// before any global flags from fn are parsed. they are then moved after those
+	// flags so that usage lists them last.
+	st.defs.guard(func() { env.setupFlags(st) })
+	builtins := st.gflags.list
+	st.gflags.list = nil
+	st.ah.noRead = st.help || st.summary
+	if !st.help && !st.summary && isTerminal(env.Stdin) {
+		st.ah.EnablePrompt(env.Prompt, env.Stderr)
+	}
+
+	var descs []cmdDesc
+	st.defs.guard(func() { descs = collectDescs(st.gflags, fn) })
+	st.gflags.list = append(st.gflags.list, builtins...)
+	st.tree = descs
 
 	executed, _, err := env.dispatchDesc(ctx, st, cmdDesc{
 		cmd:     env.Root,
 		subcmds: descs,
 	})
+	if env.inv != nil {
+		env.inv.Path = st.names
+		env.inv.Help = st.help || st.summary
+		env.inv.Errors = st.errors
+	}
 	return executed, err
 }
 
+// setupFlags defines the builtin global flags, including the optional ones
+// enabled by the environment.
+func (env *Environment) setupFlags(st *runState) {
+	st.setupFlags()
+	if env.Examples {
+		st.setupExamplesFlag()
+	}
+	if env.Color {
+		st.setupColorFlag()
+	}
+}
+
 func (env *Environment) dispatch(ctx context.Context, st *runState, descs []cmdDesc) (executed bool, matched bool, err error) {
 	name, ok, err := st.peekName()
 	if err != nil || !ok {
@@ -67,24 +174,43 @@ func (env *Environment) dispatchDesc(ctx context.Context, st *runState, desc cmd
 		return executed, matched, err
 	}
 
-	if desc.cmd != nil {
-		desc.cmd.Setup(newParams(st.pos, st.flags))
+	// find the arguments to pass through before any flags of the command are
+	// parsed. if global flags were taken from them, the run has to start over.
+	if desc.passthrough && desc.hasCmd() && st.ah.raw < 0 {
+		st.ah.raw = st.rawStart(desc)
+		if st.ah.consumedFrom(st.ah.raw) {
+			st.reparse = true
+			return false, true, nil
+		}
+	}
+
+	var cmd Command
+	if desc.hasCmd() {
+		if env.EnvPrefix != "" {
+			st.flags.envKey = envKey(append([]string{env.EnvPrefix}, st.names[1:]...)...)
+		}
+		st.flags.path = append([]string(nil), st.names...)
+		cmd = desc.instance()
+		st.defs.guard(func() { cmd.Setup(newParams(st.pos, st.flags)) })
 	}
 
-	// print usage if requested
-	if st.help {
+	// print usage if requested. definition errors are still reported below.
+	if st.help && len(st.defs.errs) == 0 {
 		env.printUsage(ctx, st, desc)
 		return true, true, nil
 	}
 
 	// print summary if required
-	if st.summary {
+	if st.summary && len(st.defs.errs) == 0 {
 		env.printSummary(ctx, st, desc)
 		return true, true, nil
 	}
 
 	// handle any errors parsing the arguments
 	if st.hasErrors() {
+		for _, err := range st.defs.errs {
+			st.errors = append(st.errors, errs.Tag("definition error").Wrap(err))
+		}
 		if !st.help {
 			st.params(func(p *param) {
 				if p != nil && p.err != nil {
@@ -92,17 +218,17 @@ func (env *Environment) dispatchDesc(ctx context.Context, st *runState, desc cmd
 				}
 			})
 		}
-		env.printUsage(ctx, st, desc)
-		return false, true, nil
+		env.printUsageErrors(ctx, st, desc)
+		return false, true, errs.Combine(st.defs.errs...)
 	}
 
 	// if we don't have a command to execute, check if it's because they
 	// specified the wrong name, and error if so.
-	if desc.cmd == nil {
+	if cmd == nil {
 		if len(desc.subcmds) > 0 {
 			env.appendUnknownCommandErrorWithSuggestions(st, desc.subcmds)
 		}
-		env.printUsage(ctx, st, desc)
+		env.printUsageErrors(ctx, st, desc)
 		return false, true, nil
 	}
 
@@ -111,22 +237,44 @@ func (env *Environment) dispatchDesc(ctx context.Context, st *runState, desc cmd
 		if err != nil {
 			st.errors = append(st.errors, err)
 		} else {
-			st.errors = append(st.errors, errs.Tag("argument error").Errorf("unknown arguments: %q", args))
+			st.errors = append(st.errors, errs.Tag("argument error").Wrap(&UsageError{
+				Kind:  UnknownArguments,
+				Value: args[0],
+				msg:   fmt.Sprintf("unknown arguments: %q", args),
+			}))
 		}
-		env.printUsage(ctx, st, desc)
+		env.printUsageErrors(ctx, st, desc)
 		return false, true, nil
 	}
 
+	// stdin comes from the args handler so that any input buffered while
+	// prompting is not lost.
 	ctx = context.WithValue(ctx, stdioKey, stdioEnvironment{
-		stdin:  env.Stdin,
+		stdin:  st.ah.stdin,
 		stdout: env.Stdout,
 		stderr: env.Stderr,
+		dir:    env.Dir,
 	})
 
+	// check the values together if the command supports it.
+	if v, ok := cmd.(Validator); ok {
+		if err := v.Validate(ctx); err != nil {
+			st.errors = append(st.errors, errs.Tag("validation error").Wrap(err))
+			env.printUsageErrors(ctx, st, desc)
+			return false, true, nil
+		}
+	}
+
+	// when only parsing, record what would have been executed.
+	if env.inv != nil {
+		env.inv.Command = cmd
+		return false, true, nil
+	}
+
 	if env.Wrap != nil {
-		err = env.Wrap(ctx, desc.cmd)
+		err = env.Wrap(ctx, cmd)
 	} else {
-		err = desc.cmd.Execute(ctx)
+		err = cmd.Execute(ctx)
 	}
 	return true, true, err
 }
@@ -1,6 +1,7 @@
 package clingy
 
 import (
+	"io"
 	"strconv"
 	"strings"
 )
@@ -11,44 +12,88 @@ type runState struct {
 	flags    *paramsFlags
 	gflags   *paramsFlags
 	names    []string
+	tree     []cmdDesc
 	errors   []error
+	defs     *definitions
 	help     bool
 	summary  bool
 	advanced bool
+	examples bool
+	color    string
+	reparse  bool // if global flags were taken from the arguments to pass through
 }
 
-func newRunState(name string, args []string, dynamic func(string) ([]string, error), getenv func(string) string) *runState {
-	pm := newParamsMaker()
-	ah := newArgsHandler(args, dynamic, getenv)
+func newRunState(name string, args []string, dynamic DynamicSource, getenv func(string) string, stdin io.Reader, sources []ValueSource) *runState {
+	defs := new(definitions)
+	pm, ppm := newParamsMaker(), newParamsMaker()
+	pm.defs, ppm.defs = defs, defs
+	ah := newArgsHandler(args, dynamic, getenv, stdin)
+	ah.sources = sources
+
+	gflags := newParamsFlags(pm, ah)
+	gflags.path, gflags.global = []string{name}, true
+	gflags.results, gflags.prev = make(map[string]flagResult), make(map[string]flagResult)
 
 	return &runState{
 		ah:     ah,
-		pos:    newParamsPositional(newParamsMaker(), ah),
+		pos:    newParamsPositional(ppm, ah),
 		flags:  newParamsFlags(pm, ah),
-		gflags: newParamsFlags(pm, ah),
+		gflags: gflags,
+		defs:   defs,
 		names:  []string{name},
 	}
 }
 
+// builtin marks the flags defined by the run state itself.
+var builtin = Option{func(po *paramOpts) { po.builtin = true }}
+
 func (st *runState) setupFlags() {
 	st.help = st.gflags.Flag(
 		"help", "prints help for the command", false,
 		Boolean,
 		Short('h'),
+		NoEnv,
+		builtin,
 		Transform(strconv.ParseBool),
 	).(bool)
 
 	st.summary = st.gflags.Flag(
 		"summary", "prints a summary of what commands are available", false,
 		Boolean,
+		NoEnv,
+		builtin,
 		Transform(strconv.ParseBool),
 	).(bool)
 
 	st.advanced = st.gflags.Flag(
 		"advanced", "when used with -h, prints advanced flags help", false,
 		Boolean,
+		NoEnv,
+		builtin,
 		Transform(strconv.ParseBool),
 	).(bool)
+
+}
+
+func (st *runState) setupExamplesFlag() {
+	st.examples = st.gflags.Flag(
+		"examples", "when used with --summary, includes examples", false,
+		Boolean,
+		Advanced,
+		NoEnv,
+		builtin,
+		Transform(strconv.ParseBool),
+	).(bool)
+}
+
+func (st *runState) setupColorFlag() {
+	st.color = st.gflags.Flag(
+		"color", "when to use colors in output", "auto",
+		Enum("auto", "always", "never"),
+		Advanced,
+		NoEnv,
+		builtin,
+	).(string)
 }
 
 func (st *runState) params(cb func(*param)) {
@@ -71,5 +116,67 @@ func (st *runState) consumeName() {
 }
 
 func (st *runState) hasErrors() bool {
-	return st.pos.hasErrors() || st.flags.hasErrors() || st.gflags.hasErrors()
+	return len(st.defs.errs) > 0 || st.pos.hasErrors() || st.flags.hasErrors() || st.gflags.hasErrors()
+}
+
+// rawStart returns the index of the first argument passed through to the
+// command described by desc. The command is set up with scratch parameters to
+// find which of its flags take values, and the real setup overwrites anything
+// that it sets.
+func (st *runState) rawStart(desc cmdDesc) int {
+	defs := &definitions{collect: true}
+	pm, ppm := newParamsMaker(), newParamsMaker()
+	pm.defs, ppm.defs = defs, defs
+	ah := newArgsHandler(nil, nil, nil, nil)
+	flags := newParamsFlags(pm, ah)
+	defs.guard(func() { desc.instance().Setup(newParams(newParamsPositional(ppm, ah), flags)) })
+
+	return st.ah.RawStart(func(name string) bool {
+		value, _ := takesValue(name, st.gflags, flags)
+		return value
+	})
+}
+
+// reuse prepares the state to parse the arguments again after prev found that
+// global flags were taken from the arguments passed through to the command. The
+// results of the global flags that were not are reused, and stdin read by any
+// that were is read again. Nothing is reused if prev did not read any values
+// because usage was requested by the flags that were taken.
+func (st *runState) reuse(prev *runState) {
+	st.ah.raw = prev.ah.raw
+	if prev.ah.noRead {
+		return
+	}
+	for name, res := range prev.gflags.results {
+		if !res.consumedFrom(prev.ah.raw) {
+			st.gflags.prev[name] = res
+		}
+	}
+
+	if by := prev.ah.stdinBy; by != "" {
+		if _, ok := st.gflags.prev[by]; ok {
+			st.ah.stdinBy = by
+		} else {
+			st.ah.stdin = io.MultiReader(strings.NewReader(prev.ah.stdinData), st.ah.stdin)
+		}
+	}
+}
+
+// takesValue returns if the flag with the name takes a value, and if the flag is
+// defined in any of the sets of flags, which may be nil.
+func takesValue(name string, sets ...*paramsFlags) (value, ok bool) {
+	for _, flags := range sets {
+		if flags == nil {
+			continue
+		}
+		flags.params(func(p *param) {
+			if p != nil && (p.name == name || (p.short != 0 && string(p.short) == name)) {
+				value, ok = !p.bstyle, true
+			}
+		})
+		if ok {
+			return value, ok
+		}
+	}
+	return false, false
 }
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -643,3 +646,916 @@ func TestRun_Wrap(t *testing.T) {
 	result := Capture(env, nil)
 	assert.That(t, errors.Is(result.Err, errs.Tag("sentinel")))
 }
+
+func TestRun_FromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payload.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"key":"value"}`), 0644))
+
+	var body string
+	root := &funcCommand{
+		SetupFn: func(params clingy.Parameters) {
+			body = params.Flag("body", "request body", "", clingy.FromFile).(string)
+			_ = params.Flag("data", "request data", "", clingy.FromFile).(string)
+		},
+		ExecuteFn: func(ctx context.Context) error { return nil },
+	}
+
+	{ // reads from a file
+		result := Run(root, "--body", "@"+path)
+		result.AssertValid(t)
+		assert.Equal(t, body, `{"key":"value"}`)
+	}
+
+	{ // reads from stdin
+		env := Env("testcommand", root, "--body", "-")
+		env.Stdin = strings.NewReader("from stdin")
+		result := Capture(env, nil)
+		result.AssertValid(t)
+		assert.Equal(t, body, "from stdin")
+	}
+
+	{ // stdin can only be consumed once
+		env := Env("testcommand", root, "--body", "-", "--data", "-")
+		env.Stdin = strings.NewReader("from stdin")
+		result := Capture(env, nil)
+		assert.That(t, !result.Ok)
+		result.AssertStdoutContains(t, `argument error: data: stdin already consumed by "body"`)
+	}
+
+	{ // missing files are an error
+		result := Run(root, "--body", "@"+path+".missing")
+		assert.That(t, !result.Ok)
+		result.AssertStdoutContains(t, "argument error: body: unable to read file:")
+	}
+
+	// nothing is read when usage is requested
+	for _, flag := range []string{"-h", "--summary"} {
+		env := Env("testcommand", root, "--body", "-", "--data", "@"+path+".missing", flag)
+		env.Stdin = readerFunc(func([]byte) (int, error) {
+			t.Error("stdin was read")
+			return 0, io.EOF
+		})
+		result := Capture(env, nil)
+		result.AssertValid(t)
+	}
+
+	{ // values are passed through transforms
+		var num int
+		root := &funcCommand{
+			SetupFn: func(params clingy.Parameters) {
+				num = params.Arg("num", "a number", clingy.FromFile, clingy.Transform(strconv.Atoi)).(int)
+			},
+			ExecuteFn: func(ctx context.Context) error { return nil },
+		}
+
+		env := Env("testcommand", root, "-")
+		env.Stdin = strings.NewReader("42")
+		result := Capture(env, nil)
+		result.AssertValid(t)
+		assert.Equal(t, num, 42)
+	}
+}
+
+func TestRun_Passthrough(t *testing.T) {
+	var (
+		timeout int
+		verbose bool
+		cmd     string
+		rest    []string
+	)
+
+	run := func(args ...string) Result {
+		return Capture(Env("testcommand", nil, args...), func(cmds clingy.Commands) {
+			verbose = cmds.Flag("verbose", "verbose output", false,
+				clingy.Boolean, clingy.Transform(strconv.ParseBool)).(bool)
+			cmds.New("exec", "execute a command", &funcCommand{
+				SetupFn: func(params clingy.Parameters) {
+					timeout = params.Flag("timeout", "timeout", 0, clingy.Transform(strconv.Atoi)).(int)
+					cmd = params.Arg("cmd", "command to run").(string)
+					rest = params.Arg("args", "arguments to the command", clingy.Repeated).([]string)
+				},
+				ExecuteFn: func(ctx context.Context) error { return nil },
+			}, clingy.Passthrough)
+		})
+	}
+
+	{ // flags after the first positional argument are passed through
+		result := run("--verbose", "exec", "--timeout", "5", "child", "-x", "--timeout", "--", "y")
+		result.AssertValid(t)
+		assert.Equal(t, verbose, true)
+		assert.Equal(t, timeout, 5)
+		assert.Equal(t, cmd, "child")
+		assert.DeepEqual(t, rest, []string{"-x", "--timeout", "--", "y"})
+	}
+
+	{ // global flags after the first positional argument are passed through
+		result := run("exec", "child", "--verbose", "--help")
+		result.AssertValid(t)
+		assert.Equal(t, verbose, false)
+		assert.Equal(t, cmd, "child")
+		assert.DeepEqual(t, rest, []string{"--verbose", "--help"})
+	}
+
+	{ // a separator begins the positional arguments
+		result := run("exec", "--", "--child", "--verbose")
+		result.AssertValid(t)
+		assert.Equal(t, cmd, "--child")
+		assert.DeepEqual(t, rest, []string{"--verbose"})
+	}
+
+	{ // help before the positional arguments is still handled
+		result := run("exec", "--help")
+		result.AssertValid(t)
+		result.AssertStdoutContains(t, "testcommand exec [flags] <cmd> [args ...]")
+	}
+}
+
+func TestRun_PassthroughGlobals(t *testing.T) {
+	var (
+		body    string
+		verbose bool
+		calls   int
+		reads   int
+		rest    []string
+	)
+
+	fn := func(cmds clingy.Commands) {
+		calls++
+		body = cmds.Flag("body", "request body", "", clingy.FromFile,
+			clingy.Transform(func(s string) (string, error) { reads++; return s, nil })).(string)
+		verbose = cmds.Flag("verbose", "verbose output", false,
+			clingy.Boolean, clingy.Transform(strconv.ParseBool)).(bool)
+		cmds.New("exec", "execute a command", &funcCommand{
+			SetupFn: func(params clingy.Parameters) {
+				rest = params.Arg("args", "arguments", clingy.Repeated).([]string)
+			},
+			ExecuteFn: func(ctx context.Context) error { return nil },
+		}, clingy.Passthrough)
+		cmds.New("other", "another command", &funcCommand{
+			SetupFn: func(params clingy.Parameters) {
+				rest = params.Arg("args", "arguments", clingy.Repeated).([]string)
+			},
+			ExecuteFn: func(ctx context.Context) error { return nil },
+		})
+	}
+
+	{ // global flags after the arguments of other commands are parsed once
+		calls, reads = 0, 0
+		result := Capture(Env("testcommand", nil, "other", "arg", "--verbose"), fn)
+		result.AssertValid(t)
+		assert.Equal(t, verbose, true)
+		assert.DeepEqual(t, rest, []string{"arg"})
+		assert.Equal(t, calls, 1)
+	}
+
+	{ // as are flags passed through that are not global flags
+		calls, reads = 0, 0
+		result := Capture(Env("testcommand", nil, "exec", "child", "-x"), fn)
+		result.AssertValid(t)
+		assert.DeepEqual(t, rest, []string{"child", "-x"})
+		assert.Equal(t, calls, 1)
+	}
+
+	{ // global flags passed through are parsed again, but no others are
+		calls, reads = 0, 0
+		env := Env("testcommand", nil, "--body", "-", "exec", "child", "--verbose")
+		env.Stdin = strings.NewReader("DATA")
+		result := Capture(env, fn)
+		result.AssertValid(t)
+		assert.Equal(t, body, "DATA")
+		assert.Equal(t, verbose, false)
+		assert.DeepEqual(t, rest, []string{"child", "--verbose"})
+		assert.Equal(t, reads, 1)
+	}
+
+	{ // values are read if usage was only requested by flags passed through
+		env := Env("testcommand", nil, "--body", "-", "exec", "child", "-h")
+		env.Stdin = strings.NewReader("DATA")
+		result := Capture(env, fn)
+		result.AssertValid(t)
+		assert.Equal(t, body, "DATA")
+		assert.DeepEqual(t, rest, []string{"child", "-h"})
+	}
+
+	{ // stdin read by a global flag passed through is available to the command
+		env := Env("testcommand", nil, "cat", "child", "--body", "-")
+		env.Stdin = strings.NewReader("DATA")
+		result := Capture(env, func(cmds clingy.Commands) {
+			fn(cmds)
+			cmds.New("cat", "read stdin", &funcCommand{
+				SetupFn: func(params clingy.Parameters) {
+					rest = params.Arg("args", "arguments", clingy.Repeated).([]string)
+				},
+				ExecuteFn: func(ctx context.Context) error {
+					_, err := io.Copy(clingy.Stdout(ctx), clingy.Stdin(ctx))
+					return err
+				},
+			}, clingy.Passthrough)
+		})
+		result.AssertValid(t)
+		result.AssertStdout(t, "DATA")
+		assert.Equal(t, body, "")
+		assert.DeepEqual(t, rest, []string{"child", "--body", "-"})
+	}
+}
+
+func TestRun_PassthroughNew(t *testing.T) {
+	var (
+		verbose bool
+		name    string
+		rest    []string
+	)
+
+	run := func(args ...string) Result {
+		return Capture(Env("testcommand", nil, args...), func(cmds clingy.Commands) {
+			cmds.New("exec", "execute a command", &funcCommand{
+				SetupFn: func(params clingy.Parameters) {
+					verbose = params.Flag("verbose", "verbose output", false,
+						clingy.Boolean, clingy.Short('v'), clingy.Transform(strconv.ParseBool)).(bool)
+					name = params.Arg("name", "command to run").(string)
+					rest = params.Arg("rest", "arguments to the command", clingy.Repeated).([]string)
+				},
+				ExecuteFn: func(ctx context.Context) error { return nil },
+			}, clingy.Passthrough)
+		})
+	}
+
+	{ // boolean flags of the command do not take the next argument
+		result := run("exec", "-v", "ls", "-la")
+		result.AssertValid(t)
+		assert.Equal(t, verbose, true)
+		assert.Equal(t, name, "ls")
+		assert.DeepEqual(t, rest, []string{"-la"})
+	}
+
+	{ // flags of the command are passed through after the arguments begin
+		result := run("exec", "--verbose", "ls", "-v")
+		result.AssertValid(t)
+		assert.Equal(t, verbose, true)
+		assert.Equal(t, name, "ls")
+		assert.DeepEqual(t, rest, []string{"-v"})
+	}
+
+	{ // without any flags
+		result := run("exec", "ls", "-v", "--", "x")
+		result.AssertValid(t)
+		assert.Equal(t, verbose, false)
+		assert.Equal(t, name, "ls")
+		assert.DeepEqual(t, rest, []string{"-v", "--", "x"})
+	}
+}
+
+func TestRun_OnUsageError(t *testing.T) {
+	run := func(args ...string) (errs []*clingy.UsageError) {
+		env := Env("testcommand", nil, args...)
+		env.OnUsageError = func(err *clingy.UsageError) { errs = append(errs, err) }
+		result := Capture(env, func(cmds clingy.Commands) {
+			cmds.New("cmd", "some command", &funcCommand{
+				SetupFn: func(params clingy.Parameters) {
+					params.Flag("num", "some number", 0, clingy.Transform(strconv.Atoi))
+					params.Arg("arg", "some argument")
+				},
+				ExecuteFn: func(ctx context.Context) error { return nil },
+			})
+		})
+		assert.That(t, !result.Ok)
+		assert.NoError(t, result.Err)
+		return errs
+	}
+
+	{ // unknown command
+		errs := run("cmb")
+		assert.Equal(t, len(errs), 1)
+		assert.Equal(t, errs[0].Kind, clingy.UnknownCommand)
+		assert.Equal(t, errs[0].Value, "cmb")
+		assert.DeepEqual(t, errs[0].Suggestions, []string{"cmd"})
+	}
+
+	{ // missing required argument
+		errs := run("cmd")
+		assert.Equal(t, len(errs), 1)
+		assert.Equal(t, errs[0].Kind, clingy.MissingRequired)
+		assert.Equal(t, errs[0].Param, "arg")
+	}
+
+	{ // bad value for a flag
+		errs := run("cmd", "--num", "foo", "arg")
+		assert.Equal(t, len(errs), 1)
+		assert.Equal(t, errs[0].Kind, clingy.InvalidValue)
+		assert.Equal(t, errs[0].Param, "num")
+		assert.Equal(t, errs[0].Value, "foo")
+		assert.That(t, errors.Is(errs[0], strconv.ErrSyntax))
+	}
+
+	{ // unknown flag
+		errs := run("cmd", "arg", "--nmu")
+		assert.Equal(t, len(errs), 1)
+		assert.Equal(t, errs[0].Kind, clingy.UnknownFlag)
+		assert.Equal(t, errs[0].Value, "--nmu")
+		assert.DeepEqual(t, errs[0].Suggestions, []string{"--num"})
+	}
+
+	{ // unknown arguments
+		errs := run("cmd", "arg", "extra")
+		assert.Equal(t, len(errs), 1)
+		assert.Equal(t, errs[0].Kind, clingy.UnknownArguments)
+		assert.Equal(t, errs[0].Value, "extra")
+	}
+}
+
+func TestRun_Parse(t *testing.T) {
+	executed := false
+	cmd := &funcCommand{
+		SetupFn: func(params clingy.Parameters) {
+			params.Arg("num", "a number", clingy.Transform(strconv.Atoi))
+		},
+		ExecuteFn: func(ctx context.Context) error { executed = true; return nil },
+	}
+	cmds := func(cmds clingy.Commands) {
+		cmds.Group("group", "a group", func() {
+			cmds.New("cmd", "a command", cmd,
+				clingy.Example("5", "a valid example"),
+				clingy.Example("five", "an invalid example"),
+			)
+		})
+	}
+
+	{ // successful parses return the command without executing it
+		env := Env("testcommand", nil, "group", "cmd", "5")
+		env.Stdout = io.Discard
+		inv, err := env.Parse(context.Background(), cmds)
+		assert.NoError(t, err)
+		assert.DeepEqual(t, inv.Path, []string{"testcommand", "group", "cmd"})
+		assert.Equal(t, inv.Command, cmd)
+		assert.That(t, !inv.Help)
+		assert.That(t, !executed)
+	}
+
+	{ // failed parses return the errors
+		env := Env("testcommand", nil, "group", "cmd", "five")
+		env.Stdout = io.Discard
+		inv, err := env.Parse(context.Background(), cmds)
+		assert.Error(t, err)
+		assert.Equal(t, len(inv.Errors), 1)
+		assert.Nil(t, inv.Command)
+
+		var ue *clingy.UsageError
+		assert.That(t, errors.As(err, &ue))
+		assert.Equal(t, ue.Value, "five")
+	}
+
+	{ // help does not return a command
+		env := Env("testcommand", nil, "group", "cmd", "-h")
+		env.Stdout = io.Discard
+		inv, err := env.Parse(context.Background(), cmds)
+		assert.NoError(t, err)
+		assert.That(t, inv.Help)
+		assert.Nil(t, inv.Command)
+	}
+
+	{ // examples are checked
+		err := Env("testcommand", nil).CheckExamples(context.Background(), cmds)
+		assert.Error(t, err)
+		assert.That(t, strings.Contains(err.Error(), `example "testcommand group cmd five"`))
+		assert.That(t, !strings.Contains(err.Error(), `example "testcommand group cmd 5"`))
+		assert.That(t, !executed)
+	}
+
+	{ // naming no command is an error
+		for _, args := range [][]string{{}, {"group"}} {
+			env := Env("testcommand", nil, args...)
+			env.Stdout = io.Discard
+			inv, err := env.Parse(context.Background(), cmds)
+			assert.Error(t, err)
+			assert.Nil(t, inv.Command)
+			assert.That(t, !inv.Help)
+		}
+	}
+}
+
+func TestRun_CheckExamplesIsolated(t *testing.T) {
+	var bodies, names []string
+	cmds := func(cmds clingy.Commands) {
+		cmds.New("cmd", "a command", &funcCommand{
+			SetupFn: func(params clingy.Parameters) {
+				bodies = append(bodies, params.Flag("body", "request body", "", clingy.FromFile).(string))
+				names = append(names, params.Flag("name", "a name", "", clingy.Getenv("NAME")).(string))
+			},
+			ExecuteFn: func(ctx context.Context) error { return nil },
+		}, clingy.Example("--body -", "reads the body from stdin"))
+	}
+
+	env := Env("testcommand", nil)
+	env.Stdin = strings.NewReader("DATA")
+	env.Getenv = func(string) string { return "bob" }
+	assert.NoError(t, env.CheckExamples(context.Background(), cmds))
+	assert.DeepEqual(t, bodies, []string{""})
+	assert.DeepEqual(t, names, []string{""})
+}
+
+func TestRun_NewFunc(t *testing.T) {
+	var created []*funcCommand
+	cmds := func(cmds clingy.Commands) {
+		cmds.NewFunc("cmd", "a command", func() clingy.Command {
+			var names []string
+			cmd := &funcCommand{}
+			cmd.SetupFn = func(params clingy.Parameters) {
+				names = append(names, params.Arg("name", "a name").(string))
+			}
+			cmd.ExecuteFn = func(ctx context.Context) error {
+				fmt.Fprint(clingy.Stdout(ctx), names)
+				return nil
+			}
+			created = append(created, cmd)
+			return cmd
+		}, clingy.Passthrough)
+	}
+
+	// every run gets a fresh instance, so state from setup is never shared.
+	for _, name := range []string{"a", "b"} {
+		result := Capture(Env("testcommand", nil, "cmd", "--", name), cmds)
+		result.AssertValid(t)
+		result.AssertStdout(t, "["+name+"]")
+	}
+
+	// finding the arguments to pass through uses its own instance as well.
+	assert.Equal(t, len(created), 4)
+
+	// parsing returns the instance that would have been executed.
+	env := Env("testcommand", nil, "cmd", "c")
+	inv, err := env.Parse(context.Background(), cmds)
+	assert.NoError(t, err)
+	assert.Equal(t, inv.Command, created[len(created)-1])
+}
+
+func TestRun_CollectDefinitionErrors(t *testing.T) {
+	executed := false
+	cmds := func(cmds clingy.Commands) {
+		cmds.New("bad", "a command with mistakes", &funcCommand{
+			SetupFn: func(params clingy.Parameters) {
+				params.Flag("foo", "some flag", 0, clingy.Short('f'))
+				params.Flag("bar", "some flag again", 0, clingy.Short('f'))
+				_ = params.Flag("baz", "some flag", 0, clingy.Transform(func() bool { return false })).(bool)
+			},
+			ExecuteFn: func(ctx context.Context) error { executed = true; return nil },
+		})
+		cmds.New("good", "a command without mistakes", printCommand("good"))
+	}
+
+	{ // unrelated commands still work
+		env := Env("testcommand", nil, "good")
+		env.CollectDefinitionErrors = true
+		result := Capture(env, cmds)
+		result.AssertValid(t)
+		result.AssertStdout(t, "good")
+	}
+
+	{ // the mistakes are reported for the command with them
+		env := Env("testcommand", nil, "bad")
+		env.CollectDefinitionErrors = true
+		result := Capture(env, cmds)
+		assert.That(t, !result.Ok)
+		assert.That(t, !executed)
+		result.AssertStdout(t, `
+			Errors:
+			    definition error: parameter already defined with short-name: 'f'
+			    definition error: parameter has invalid transformation functions: transform: func() bool cannot be applied to string
+
+			Usage:
+			    testcommand bad [flags]
+
+			    a command with mistakes
+
+			Flags:
+			    -f, --foo string    some flag
+
+			Global flags:
+			    -h, --help         prints help for the command
+			        --summary      prints a summary of what commands are available
+			        --advanced     when used with -h, prints advanced flags help
+		`)
+
+		var de *clingy.DefinitionError
+		assert.That(t, errors.As(result.Err, &de))
+		assert.Equal(t, de.Param, "bar")
+	}
+
+	{ // the mistakes are reported when usage is requested
+		for _, flag := range []string{"-h", "--summary"} {
+			env := Env("testcommand", nil, "bad", flag)
+			env.CollectDefinitionErrors = true
+			result := Capture(env, cmds)
+			assert.That(t, !result.Ok)
+			result.AssertStdoutContains(t, "definition error: parameter already defined with short-name: 'f'")
+
+			var de *clingy.DefinitionError
+			assert.That(t, errors.As(result.Err, &de))
+		}
+	}
+}
+
+type validateCommand struct {
+	funcCommand
+	ValidateFn func(ctx context.Context) error
+}
+
+func (cmd *validateCommand) Validate(ctx context.Context) error { return cmd.ValidateFn(ctx) }
+
+func TestRun_Validator(t *testing.T) {
+	var start, end int
+	executed := false
+	cmd := &validateCommand{
+		funcCommand: funcCommand{
+			SetupFn: func(params clingy.Parameters) {
+				start = params.Flag("start", "first value", 0, clingy.Transform(strconv.Atoi)).(int)
+				end = params.Flag("end", "last value", 0, clingy.Transform(strconv.Atoi)).(int)
+			},
+			ExecuteFn: func(ctx context.Context) error { executed = true; return nil },
+		},
+		ValidateFn: func(ctx context.Context) error {
+			if start > end {
+				return errs.Errorf("--start must not be after --end")
+			}
+			return nil
+		},
+	}
+
+	{ // valid combinations execute
+		result := Run(cmd, "--start", "1", "--end", "2")
+		result.AssertValid(t)
+		assert.That(t, executed)
+	}
+
+	{ // invalid combinations print usage and do not execute
+		executed = false
+		result := Run(cmd, "--start", "3", "--end", "2")
+		assert.That(t, !result.Ok)
+		assert.NoError(t, result.Err)
+		assert.That(t, !executed)
+		result.AssertStdout(t, `
+			Errors:
+			    validation error: --start must not be after --end
+
+			Usage:
+			    testcommand [flags]
+
+			Flags:
+			        --start int    first value
+			        --end int      last value
+
+			Global flags:
+			    -h, --help         prints help for the command
+			        --summary      prints a summary of what commands are available
+			        --advanced     when used with -h, prints advanced flags help
+		`)
+	}
+
+	{ // parsing validates as well
+		env := Env("testcommand", cmd, "--start", "3", "--end", "2")
+		env.Stdout = io.Discard
+		inv, err := env.Parse(context.Background(), nil)
+		assert.Error(t, err)
+		assert.Nil(t, inv.Command)
+	}
+}
+
+func TestRun_EnvPrefix(t *testing.T) {
+	var verbose, dryRun, force string
+	cmds := func(cmds clingy.Commands) {
+		verbose = cmds.Flag("verbose", "prints more", "").(string)
+		cmds.Group("repo", "repository commands", func() {
+			cmds.New("sync", "syncs the repository", &funcCommand{
+				SetupFn: func(params clingy.Parameters) {
+					dryRun = params.Flag("dry-run", "does nothing", "").(string)
+					force = params.Flag("force", "forces it", "", clingy.NoEnv).(string)
+					params.Flag("token", "the token", "", clingy.Getenv("TOKEN"))
+				},
+				ExecuteFn: func(ctx context.Context) error { return nil },
+			})
+		})
+	}
+	vars := map[string]string{
+		"MYTOOL_VERBOSE":           "yes",
+		"MYTOOL_REPO_SYNC_DRY_RUN": "true",
+		"MYTOOL_REPO_SYNC_FORCE":   "true",
+	}
+
+	{ // flags are bound to their environment variables
+		env := Env("mytool", nil, "repo", "sync")
+		env.EnvPrefix = "mytool"
+		env.Getenv = func(key string) string { return vars[key] }
+		result := Capture(env, cmds)
+		result.AssertValid(t)
+		assert.Equal(t, verbose, "yes")
+		assert.Equal(t, dryRun, "true")
+		assert.Equal(t, force, "")
+	}
+
+	{ // usage shows the variables
+		env := Env("mytool", nil, "repo", "sync", "-h")
+		env.EnvPrefix = "mytool"
+		result := Capture(env, cmds)
+		result.AssertValid(t)
+		result.AssertStdout(t, `
+			Usage:
+			    mytool repo sync [flags]
+
+			    syncs the repository
+
+			Flags:
+			        --dry-run string    does nothing (env MYTOOL_REPO_SYNC_DRY_RUN)
+			        --force string      forces it
+			        --token string      the token (env TOKEN)
+
+			Global flags:
+			        --verbose string    prints more (env MYTOOL_VERBOSE)
+			    -h, --help              prints help for the command
+			        --summary           prints a summary of what commands are available
+			        --advanced          when used with -h, prints advanced flags help
+		`)
+	}
+}
+
+func TestRun_EnvArgs(t *testing.T) {
+	var (
+		name  string
+		paths []string
+		tags  []string
+	)
+	root := &funcCommand{
+		SetupFn: func(params clingy.Parameters) {
+			tags = params.Flag("tag", "tags to apply", nil, clingy.Repeated,
+				clingy.Getenv("TAGS"), clingy.EnvSeparator(",")).([]string)
+			name = params.Arg("name", "the name", clingy.Getenv("NAME")).(string)
+			paths = params.Arg("paths", "where to look", clingy.Repeated,
+				clingy.Getenv("PATHS"), clingy.EnvSeparator(":")).([]string)
+		},
+		ExecuteFn: func(ctx context.Context) error { return nil },
+	}
+	vars := map[string]string{"NAME": "bob", "PATHS": "/a:/b::/c", "TAGS": "x,y"}
+	env := func(args ...string) clingy.Environment {
+		env := Env("testcommand", root, args...)
+		env.Getenv = func(key string) string { return vars[key] }
+		return env
+	}
+
+	{ // arguments and repeated values come from the environment
+		result := Capture(env(), nil)
+		result.AssertValid(t)
+		assert.Equal(t, name, "bob")
+		assert.DeepEqual(t, paths, []string{"/a", "/b", "/c"})
+		assert.DeepEqual(t, tags, []string{"x", "y"})
+	}
+
+	{ // specified values take precedence
+		result := Capture(env("--tag", "z", "alice", "/d"), nil)
+		result.AssertValid(t)
+		assert.Equal(t, name, "alice")
+		assert.DeepEqual(t, paths, []string{"/d"})
+		assert.DeepEqual(t, tags, []string{"z"})
+	}
+
+	{ // usage shows the variables
+		result := Capture(env("-h"), nil)
+		result.AssertValid(t)
+		result.AssertStdout(t, `
+			Usage:
+			    testcommand [flags] <name> [paths ...]
+
+			Arguments:
+			    name     the name (env NAME)
+			    paths    where to look (env PATHS, separated by ":")
+
+			Flags:
+			        --tag string    tags to apply (repeated) (env TAGS, separated by ",")
+
+			Global flags:
+			    -h, --help         prints help for the command
+			        --summary      prints a summary of what commands are available
+			        --advanced     when used with -h, prints advanced flags help
+		`)
+	}
+}
+
+func TestRun_DynamicSource(t *testing.T) {
+	var region, replicas string
+	var lookups []clingy.DynamicFlag
+	cmds := func(cmds clingy.Commands) {
+		region = cmds.Flag("region", "the region", "").(string)
+		cmds.Group("db", "database commands", func() {
+			cmds.New("scale", "scales the database", &funcCommand{
+				SetupFn: func(params clingy.Parameters) {
+					replicas = params.Flag("replicas", "number of replicas", "1").(string)
+				},
+				ExecuteFn: func(ctx context.Context) error { return nil },
+			})
+		})
+	}
+	// the builtin global flags are looked up before any others so that prompting
+	// can be enabled before the global flags of the commands are parsed, so they
+	// are not recorded.
+	builtins := map[string]bool{"help": true, "summary": true, "advanced": true}
+	source := clingy.DynamicSourceFunc(func(flag clingy.DynamicFlag) ([]string, error) {
+		if !builtins[flag.Name] {
+			lookups = append(lookups, flag)
+		}
+		switch strings.Join(append(flag.Path, flag.Name), " ") {
+		case "testcommand region":
+			return []string{"us-east"}, nil
+		case "testcommand db scale replicas":
+			return []string{"3"}, nil
+		}
+		return nil, clingy.ErrNotFound
+	})
+
+	{ // global and command flags are looked up with their path
+		env := Env("testcommand", nil, "db", "scale")
+		env.DynamicSource = source
+		result := Capture(env, cmds)
+		result.AssertValid(t)
+		assert.Equal(t, region, "us-east")
+		assert.Equal(t, replicas, "3")
+		assert.DeepEqual(t, lookups[0], clingy.DynamicFlag{Path: []string{"testcommand"}, Name: "region", Global: true})
+		assert.DeepEqual(t, lookups[len(lookups)-1], clingy.DynamicFlag{Path: []string{"testcommand", "db", "scale"}, Name: "replicas"})
+	}
+
+	{ // specified flags are not looked up
+		lookups = nil
+		env := Env("testcommand", nil, "db", "scale", "--replicas", "5")
+		env.DynamicSource = source
+		result := Capture(env, cmds)
+		result.AssertValid(t)
+		assert.Equal(t, replicas, "5")
+		for _, flag := range lookups {
+			assert.That(t, flag.Name != "replicas")
+		}
+	}
+
+	{ // errors fail the parse
+		env := Env("testcommand", nil, "db", "scale")
+		env.DynamicSource = clingy.DynamicSourceFunc(func(flag clingy.DynamicFlag) ([]string, error) {
+			if flag.Name == "replicas" {
+				return nil, errs.Errorf("backend unavailable")
+			}
+			return nil, clingy.ErrNotFound
+		})
+		result := Capture(env, cmds)
+		assert.That(t, !result.Ok)
+		result.AssertStdoutContains(t, "backend unavailable")
+	}
+}
+
+type mapSource map[string]string
+
+func (m mapSource) Lookup(flag clingy.DynamicFlag) ([]string, error) {
+	if val, ok := m[flag.Name]; ok {
+		return []string{val}, nil
+	}
+	return nil, clingy.ErrNotFound
+}
+
+func (m mapSource) Keys() (keys []string, err error) {
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func TestRun_Sources(t *testing.T) {
+	var name, color string
+	root := &funcCommand{
+		SetupFn: func(params clingy.Parameters) {
+			name = params.Flag("name", "a name", "default", clingy.Getenv("NAME")).(string)
+			color = params.Flag("color", "a color", "default", clingy.Getenv("COLOR")).(string)
+		},
+		ExecuteFn: func(ctx context.Context) error { return nil },
+	}
+	config := mapSource{"name": "config"}
+	env := func(sources []clingy.ValueSource, args ...string) clingy.Environment {
+		env := Env("testcommand", root, args...)
+		env.Getenv = func(key string) string { return map[string]string{"NAME": "env", "COLOR": "env"}[key] }
+		env.Sources = sources
+		return env
+	}
+
+	{ // the default chain prefers the command line, then the environment
+		result := Capture(env(nil, "--color", "args"), nil)
+		result.AssertValid(t)
+		assert.Equal(t, name, "env")
+		assert.Equal(t, color, "args")
+	}
+
+	{ // sources are consulted in order
+		sources := []clingy.ValueSource{clingy.CommandLine, config, clingy.EnvVars}
+		result := Capture(env(sources, "--color", "args"), nil)
+		result.AssertValid(t)
+		assert.Equal(t, name, "config")
+		assert.Equal(t, color, "args")
+	}
+
+	{ // the command line is consumed even if a source takes precedence
+		sources := []clingy.ValueSource{config, clingy.CommandLine}
+		result := Capture(env(sources, "--name", "args"), nil)
+		result.AssertValid(t)
+		assert.Equal(t, name, "config")
+		assert.Equal(t, color, "default")
+	}
+
+	{ // flags on the command line are an error without the command line source
+		sources := []clingy.ValueSource{clingy.EnvVars}
+		result := Capture(env(sources, "--name", "args"), nil)
+		assert.That(t, !result.Ok)
+		result.AssertStdoutContains(t, "name: flag cannot be specified on the command line")
+	}
+
+	{ // except for the builtin flags
+		sources := []clingy.ValueSource{clingy.EnvVars}
+		result := Capture(env(sources, "-h"), nil)
+		result.AssertValid(t)
+		result.AssertStdoutContains(t, "Usage:")
+	}
+
+	{ // arguments only use environment variables with the environment source
+		var arg string
+		fn := func(cmds clingy.Commands) {
+			cmds.New("cmd", "a command", &funcCommand{
+				SetupFn: func(params clingy.Parameters) {
+					arg = params.Arg("arg", "an argument", clingy.Getenv("NAME")).(string)
+				},
+				ExecuteFn: func(ctx context.Context) error { return nil },
+			})
+		}
+
+		result := Capture(env(nil, "cmd"), fn)
+		result.AssertValid(t)
+		assert.Equal(t, arg, "env")
+
+		result = Capture(env([]clingy.ValueSource{clingy.CommandLine}, "cmd"), fn)
+		assert.That(t, !result.Ok)
+		result.AssertStdoutContains(t, "arg: required argument missing")
+	}
+
+	keys, err := config.Keys()
+	assert.NoError(t, err)
+	assert.DeepEqual(t, keys, []string{"name"})
+}
+
+func TestRun_SecretRedaction(t *testing.T) {
+	root := &funcCommand{
+		SetupFn: func(params clingy.Parameters) {
+			params.Flag("token", "the api token", "hunter2", clingy.Secret)
+			params.Flag("pin", "the pin", 0, clingy.Secret, clingy.Transform(strconv.Atoi))
+			params.Flag("level", "the level", "low", clingy.Secret, clingy.Enum("low", "high"))
+		},
+		ExecuteFn: func(ctx context.Context) error { return nil },
+	}
+
+	{ // defaults are redacted in usage
+		result := Run(root, "-h")
+		result.AssertValid(t)
+		result.AssertStdout(t, `
+			Usage:
+			    testcommand [flags]
+
+			Flags:
+			        --token string    the api token (default <redacted>)
+			        --pin int         the pin
+			        --level string    the level (one of low, high) (default <redacted>)
+
+			Global flags:
+			    -h, --help         prints help for the command
+			        --summary      prints a summary of what commands are available
+			        --advanced     when used with -h, prints advanced flags help
+		`)
+	}
+
+	{ // values are redacted from errors
+		var usageErrors []*clingy.UsageError
+		env := Env("testcommand", root, "--pin", "12ab34", "--level", "s3cr3t")
+		env.OnUsageError = func(err *clingy.UsageError) { usageErrors = append(usageErrors, err) }
+		result := Capture(env, nil)
+		assert.That(t, !result.Ok)
+		assert.That(t, !strings.Contains(result.Stdout, "12ab34"))
+		assert.That(t, !strings.Contains(result.Stdout, "s3cr3t"))
+		result.AssertStdoutContains(t, `argument error: pin: invalid value <redacted>`)
+		result.AssertStdoutContains(t, `argument error: level: invalid value <redacted>`)
+
+		assert.Equal(t, len(usageErrors), 2)
+		for _, ue := range usageErrors {
+			assert.That(t, ue.Secret)
+			assert.Equal(t, ue.Value, "<redacted>")
+			assert.That(t, !strings.Contains(fmt.Sprintf("%+v", ue), "12ab34"))
+			var numErr *strconv.NumError
+			assert.That(t, !errors.As(ue, &numErr))
+		}
+	}
+
+	// one character values do not garble the rest of the message
+	for _, value := range []string{"a", "e"} {
+		result := Run(root, "--pin", value)
+		assert.That(t, !result.Ok)
+		result.AssertStdoutContains(t, "Errors:\n    argument error: pin: invalid value <redacted>\n")
+		assert.Equal(t, strings.Count(result.Stdout, "<redacted>"), 3)
+	}
+}
@@ -0,0 +1,304 @@
+package clingy
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/zeebo/errs/v2"
+)
+
+// Serve accepts connections from the listener until the context is canceled
+// and runs the commands created by fn for each of them. Clients connect with
+// Call, which sends the arguments, environment variables and working directory
+// to use and streams stdin, stdout and stderr over the connection, and finally
+// receives the exit code. Every connection runs with a copy of env where the
+// Args, Getenv, Dir and stdio are replaced with those from the client. Because
+// the working directory of the server is shared, commands must resolve relative
+// paths against Dir to use the one of the client.
+//
+// Serve closes the listener and waits for every connection to finish before
+// returning. It returns nil if it stopped because the context was canceled.
+func Serve(ctx context.Context, lis net.Listener, env Environment, fn func(Commands)) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		_ = lis.Close()
+	}()
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errs.Wrap(err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveConn(ctx, conn, env, fn)
+		}()
+	}
+}
+
+// serveConn runs a single invocation sent by a client over conn.
+func serveConn(ctx context.Context, conn net.Conn, env Environment, fn func(Commands)) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer func() { _ = conn.Close() }()
+
+	// ensure blocked reads and writes return if the context is canceled.
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	kind, data, err := readFrame(conn)
+	if err != nil || kind != frameRequest {
+		return
+	}
+	var req serveRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return
+	}
+
+	vars := make(map[string]string, len(req.Environ))
+	for _, kv := range req.Environ {
+		if idx := strings.IndexByte(kv, '='); idx >= 0 {
+			vars[kv[:idx]] = kv[idx+1:]
+		}
+	}
+
+	// stdin frames are copied into a pipe until an empty frame marks the end
+	// of stdin. any errors reading from the client cancel the invocation.
+	stdin, stdinw := io.Pipe()
+	go func() {
+		defer cancel()
+		for {
+			kind, data, err := readFrame(conn)
+			if err != nil {
+				_ = stdinw.CloseWithError(err)
+				return
+			} else if kind != frameStdin {
+				continue
+			} else if len(data) == 0 {
+				_ = stdinw.Close()
+				continue
+			}
+			if _, err := stdinw.Write(data); err != nil {
+				// the command stopped reading stdin. discard the rest.
+				continue
+			}
+		}
+	}()
+	defer func() { _ = stdin.Close() }()
+
+	// the arguments must not default to those of the server.
+	if req.Args == nil {
+		req.Args = []string{}
+	}
+
+	fw := &frameWriter{w: conn}
+	env.Args = req.Args
+	env.Getenv = func(key string) string { return vars[key] }
+	env.Dir = req.Dir
+	env.Stdin = stdin
+	env.Stdout = fw.stream(frameStdout)
+	env.Stderr = fw.stream(frameStderr)
+	env.fillDefaults()
+
+	code := runServed(ctx, env, fn)
+
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(code))
+	_ = fw.write(frameExit, buf[:])
+}
+
+// runServed runs the commands and returns the exit code for the client. Panics
+// are reported to the client instead of stopping the server.
+func runServed(ctx context.Context, env Environment, fn func(Commands)) (code int) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			fmt.Fprintf(env.Stderr, "panic: %v\n", rec)
+			code = 2
+		}
+	}()
+
+	ok, err := env.Run(ctx, fn)
+	if err != nil {
+		fmt.Fprintf(env.Stderr, "%s: %v\n", env.Name, err)
+	}
+	if !ok || err != nil {
+		return 1
+	}
+	return 0
+}
+
+// Call sends an invocation to a server started with Serve over conn and returns
+// the exit code of the invocation. The Args, Dir and stdio of env are used, with
+// Dir defaulting to the working directory, and environ is the list of "key=value"
+// environment variables, defaulting to os.Environ if nil. The connection is closed
+// when Call returns.
+//
+// Stdin is copied to the server in the background. Call does not wait for a read
+// from Stdin that is blocked when it returns. Data returned by such a read is
+// discarded, after which the copy stops.
+func Call(ctx context.Context, conn net.Conn, env Environment, environ []string) (int, error) {
+	env.fillDefaults()
+	if environ == nil {
+		environ = os.Environ()
+	}
+	if env.Dir == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return 0, errs.Wrap(err)
+		}
+		env.Dir = dir
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer func() { _ = conn.Close() }()
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	data, err := json.Marshal(serveRequest{Args: env.Args, Environ: environ, Dir: env.Dir})
+	if err != nil {
+		return 0, errs.Wrap(err)
+	}
+	fw := &frameWriter{w: conn}
+	if err := fw.write(frameRequest, data); err != nil {
+		return 0, errs.Wrap(err)
+	}
+
+	// stdin is streamed until it is exhausted, after which an empty frame
+	// lets the server know there is no more.
+	go func() {
+		_, err := io.Copy(fw.stream(frameStdin), env.Stdin)
+		if err == nil {
+			_ = fw.write(frameStdin, nil)
+		}
+	}()
+
+	for {
+		kind, data, err := readFrame(conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			return 0, errs.Wrap(err)
+		}
+
+		switch kind {
+		case frameStdout:
+			_, err = env.Stdout.Write(data)
+		case frameStderr:
+			_, err = env.Stderr.Write(data)
+		case frameExit:
+			if len(data) != 4 {
+				return 0, errs.Errorf("invalid exit frame")
+			}
+			return int(int32(binary.BigEndian.Uint32(data))), nil
+		}
+		if err != nil {
+			return 0, errs.Wrap(err)
+		}
+	}
+}
+
+// serveRequest is the first frame sent by a client.
+type serveRequest struct {
+	Args    []string `json:"args"`
+	Environ []string `json:"environ"`
+	Dir     string   `json:"dir"`
+}
+
+// frames are a single kind byte followed by a big endian uint32 length and
+// then that many bytes of data.
+const (
+	frameRequest byte = 'r'
+	frameStdin   byte = '0'
+	frameStdout  byte = '1'
+	frameStderr  byte = '2'
+	frameExit    byte = 'x'
+)
+
+// maxFrameSize is the largest frame that will be read.
+const maxFrameSize = 1 << 20
+
+func readFrame(r io.Reader) (kind byte, data []byte, err error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(hdr[1:])
+	if size > maxFrameSize {
+		return 0, nil, errs.Errorf("frame too large: %d bytes", size)
+	}
+	data = make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	return hdr[0], data, nil
+}
+
+// frameWriter writes frames, allowing multiple streams to share the writer.
+type frameWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (fw *frameWriter) write(kind byte, data []byte) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	var hdr [5]byte
+	hdr[0] = kind
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(data)))
+	if _, err := fw.w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := fw.w.Write(data)
+	return err
+}
+
+func (fw *frameWriter) stream(kind byte) io.Writer {
+	return frameStream{fw: fw, kind: kind}
+}
+
+// frameStream is an io.Writer that writes its data as frames of one kind.
+type frameStream struct {
+	fw   *frameWriter
+	kind byte
+}
+
+func (fs frameStream) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxFrameSize {
+			chunk = chunk[:maxFrameSize]
+		}
+		if err := fs.fw.write(fs.kind, chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
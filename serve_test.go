@@ -0,0 +1,147 @@
+package clingy_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/assert"
+	"github.com/zeebo/errs/v2"
+
+	"github.com/zeebo/clingy"
+)
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lis, err := net.Listen("unix", filepath.Join(t.TempDir(), "sock"))
+	assert.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- clingy.Serve(ctx, lis, clingy.Environment{Name: "testcommand"}, func(cmds clingy.Commands) {
+			var name string
+			cmds.New("echo", "copies stdin", &funcCommand{
+				SetupFn: func(params clingy.Parameters) {
+					name = params.Flag("name", "a name", "", clingy.Getenv("NAME")).(string)
+				},
+				ExecuteFn: func(ctx context.Context) error {
+					_, _ = io.WriteString(clingy.Stdout(ctx), name)
+					_, err := io.Copy(clingy.Stdout(ctx), clingy.Stdin(ctx))
+					return err
+				},
+			})
+			cmds.New("fail", "returns an error", &funcCommand{
+				SetupFn:   func(params clingy.Parameters) {},
+				ExecuteFn: func(ctx context.Context) error { return errs.Errorf("boom") },
+			})
+			var body string
+			cmds.New("body", "prints a body", &funcCommand{
+				SetupFn: func(params clingy.Parameters) {
+					body = params.Flag("body", "a body", "", clingy.FromFile).(string)
+				},
+				ExecuteFn: func(ctx context.Context) error {
+					_, err := io.WriteString(clingy.Stdout(ctx), body+" in "+clingy.Dir(ctx))
+					return err
+				},
+			})
+		})
+	}()
+
+	dir := ""
+	call := func(stdin string, environ []string, args ...string) (int, string, string) {
+		conn, err := net.Dial("unix", lis.Addr().String())
+		assert.NoError(t, err)
+
+		var stdout, stderr bytes.Buffer
+		code, err := clingy.Call(ctx, conn, clingy.Environment{
+			Args:   args,
+			Dir:    dir,
+			Stdin:  strings.NewReader(stdin),
+			Stdout: &stdout,
+			Stderr: &stderr,
+		}, environ)
+		assert.NoError(t, err)
+		return code, stdout.String(), stderr.String()
+	}
+
+	{ // stdin is streamed to the command and stdout back to the client
+		code, stdout, stderr := call("hello world", []string{}, "echo")
+		assert.Equal(t, code, 0)
+		assert.Equal(t, stdout, "hello world")
+		assert.Equal(t, stderr, "")
+	}
+
+	{ // usage errors have a non-zero exit code
+		code, stdout, _ := call("", []string{}, "echo", "--unknown")
+		assert.Equal(t, code, 1)
+		assert.That(t, strings.Contains(stdout, "Errors:"))
+	}
+
+	{ // the environment is the one sent by the client
+		code, stdout, _ := call("!", []string{"NAME=bob"}, "echo")
+		assert.Equal(t, code, 0)
+		assert.Equal(t, stdout, "bob!")
+	}
+
+	{ // relative files are read from the directory of the client
+		dir = t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "body.txt"), []byte("data"), 0644))
+		code, stdout, _ := call("", []string{}, "body", "--body", "@body.txt")
+		assert.Equal(t, code, 0)
+		assert.Equal(t, stdout, "data in "+dir)
+	}
+
+	{ // which defaults to the working directory
+		dir = ""
+		wd, err := os.Getwd()
+		assert.NoError(t, err)
+		code, stdout, _ := call("", []string{}, "body")
+		assert.Equal(t, code, 0)
+		assert.Equal(t, stdout, " in "+wd)
+	}
+
+	{ // errors are reported without the stack of the server
+		code, _, stderr := call("", []string{}, "fail")
+		assert.Equal(t, code, 1)
+		assert.Equal(t, stderr, "testcommand: boom\n")
+	}
+
+	{ // missing arguments are not taken from the server
+		conn, err := net.Dial("unix", lis.Addr().String())
+		assert.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		req := `{"args":null}`
+		_, err = conn.Write(append([]byte{'r', 0, 0, 0, byte(len(req))}, req...))
+		assert.NoError(t, err)
+		_, err = conn.Write([]byte{'0', 0, 0, 0, 0})
+		assert.NoError(t, err)
+
+		var frames []byte
+		for {
+			var hdr [5]byte
+			_, err := io.ReadFull(conn, hdr[:])
+			assert.NoError(t, err)
+			data := make([]byte, binary.BigEndian.Uint32(hdr[1:]))
+			_, err = io.ReadFull(conn, data)
+			assert.NoError(t, err)
+			if hdr[0] == 'x' {
+				break
+			}
+			frames = append(frames, data...)
+		}
+		assert.That(t, strings.Contains(string(frames), "Available commands:"))
+		assert.That(t, !strings.Contains(string(frames), "Errors:"))
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,226 @@
+package clingy
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SetupStruct defines a flag or argument for every field of the struct pointed
+// to by v that has a `clingy` tag, in the order of the fields, and stores their
+// values into the fields. It is intended to be called from a command's Setup.
+//
+// The tag starts with "flag" or "arg" and is followed by comma separated options:
+//
+//	name=verbose   the name of the flag or argument (default: the field name in kebab-case)
+//	short=v        the Short name of the flag
+//	env=VERBOSE    the environment variable for the flag or argument, as with Getenv
+//	envsep=:       the separator of values in the environment variable, as with EnvSeparator
+//	enum=a|b|c     the allowed values, as with Enum
+//	required       the flag must be specified (arguments are required unless they are pointers or slices)
+//	advanced       the flag is Advanced
+//	hidden         the flag is Hidden
+//	file           the value may be read from a file, as with FromFile
+//	prompt         the value is asked for, as with Prompt
+//	secret         the value is hidden when asked for and redacted from usage and errors, as with Secret
+//	noenv          the flag is not bound by the Environment's EnvPrefix, as with NoEnv
+//
+// The description is taken from a separate `desc` tag. The current value of the
+// field is the default for a flag, including non-nil pointers and non-empty
+// slices. Fields may be strings, bools, integers, floats or time.Durations,
+// pointers to those to make them Optional, or slices of those to make them
+// Repeated. Bool flags are Boolean.
+//
+// SetupStruct panics if v is not a pointer to a struct or if a tag or field type
+// is not supported.
+func SetupStruct(params Parameters, v interface{}) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("SetupStruct requires a pointer to a struct: %T", v))
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("clingy")
+		if !ok {
+			continue
+		}
+		if field.PkgPath != "" {
+			panic(fmt.Sprintf("SetupStruct field is not exported: %s", field.Name))
+		}
+
+		st := parseStructTag(field, tag)
+		fv := rv.Field(i)
+
+		var val interface{}
+		if st.arg {
+			val = params.Arg(st.name, st.desc, st.options...)
+		} else {
+			def := fv.Interface()
+			if st.required {
+				def = Required
+			} else if (field.Type.Kind() == reflect.Ptr || field.Type.Kind() == reflect.Slice) && fv.IsNil() {
+				def = nil
+			}
+			val = params.Flag(st.name, st.desc, def, st.options...)
+
+			// repeated flags are empty rather than their default when missing.
+			if val != nil && field.Type.Kind() == reflect.Slice && reflect.ValueOf(val).Len() == 0 {
+				val = nil
+			}
+		}
+
+		if val != nil {
+			fv.Set(reflect.ValueOf(val))
+		}
+	}
+}
+
+type structTag struct {
+	arg      bool
+	name     string
+	desc     string
+	required bool
+	options  []Option
+}
+
+func parseStructTag(field reflect.StructField, tag string) (st structTag) {
+	parts := strings.Split(tag, ",")
+	switch parts[0] {
+	case "arg":
+		st.arg = true
+	case "flag":
+	default:
+		panic(fmt.Sprintf("SetupStruct tag must start with flag or arg: %s: %q", field.Name, tag))
+	}
+
+	st.name = kebabCase(field.Name)
+	st.desc = field.Tag.Get("desc")
+
+	for _, part := range parts[1:] {
+		key, value := part, ""
+		if idx := strings.IndexByte(part, '='); idx >= 0 {
+			key, value = part[:idx], part[idx+1:]
+		}
+
+		switch key {
+		case "name":
+			st.name = value
+		case "short":
+			if len(value) != 1 {
+				panic(fmt.Sprintf("SetupStruct short must be a single character: %s: %q", field.Name, value))
+			}
+			st.options = append(st.options, Short(value[0]))
+		case "env":
+			st.options = append(st.options, Getenv(value))
+		case "envsep":
+			st.options = append(st.options, EnvSeparator(value))
+		case "enum":
+			st.options = append(st.options, Enum(strings.Split(value, "|")...))
+		case "required":
+			if st.arg {
+				panic(fmt.Sprintf("SetupStruct required is only supported for flags: %s: %q", field.Name, tag))
+			}
+			st.required = true
+		case "advanced":
+			st.options = append(st.options, Advanced)
+		case "hidden":
+			st.options = append(st.options, Hidden)
+		case "file":
+			st.options = append(st.options, FromFile)
+		case "prompt":
+			st.options = append(st.options, Prompt)
+		case "secret":
+			st.options = append(st.options, Secret)
+		case "noenv":
+			st.options = append(st.options, NoEnv)
+		default:
+			panic(fmt.Sprintf("SetupStruct tag has unknown option: %s: %q", field.Name, part))
+		}
+	}
+
+	typ := field.Type
+	switch typ.Kind() {
+	case reflect.Ptr:
+		st.options = append(st.options, Optional)
+		typ = typ.Elem()
+	case reflect.Slice:
+		st.options = append(st.options, Repeated)
+		typ = typ.Elem()
+	}
+
+	fn, ok := structTransform(typ)
+	if !ok {
+		panic(fmt.Sprintf("SetupStruct field has unsupported type: %s: %v", field.Name, field.Type))
+	}
+	if fn != nil {
+		st.options = append(st.options, Transform(fn))
+	}
+	if typ.Kind() == reflect.Bool && !st.arg {
+		st.options = append(st.options, Boolean)
+	}
+	return st
+}
+
+// structTransform returns the transform function that parses a string into
+// a value of the type. It returns nil if the type is a string.
+func structTransform(typ reflect.Type) (fn interface{}, ok bool) {
+	if typ == durationType {
+		return time.ParseDuration, true
+	}
+
+	var parse func(string) (interface{}, error)
+	switch typ.Kind() {
+	case reflect.String:
+		if typ == stringType {
+			return nil, true
+		}
+		parse = func(s string) (interface{}, error) { return s, nil }
+	case reflect.Bool:
+		parse = func(s string) (interface{}, error) { return strconv.ParseBool(s) }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parse = func(s string) (interface{}, error) { return strconv.ParseInt(s, 10, typ.Bits()) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parse = func(s string) (interface{}, error) { return strconv.ParseUint(s, 10, typ.Bits()) }
+	case reflect.Float32, reflect.Float64:
+		parse = func(s string) (interface{}, error) { return strconv.ParseFloat(s, typ.Bits()) }
+	default:
+		return nil, false
+	}
+
+	// build a func(string) (T, error) so that the parameter has the field's type.
+	fnType := reflect.FuncOf([]reflect.Type{stringType}, []reflect.Type{typ, errorType}, false)
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		v, err := parse(args[0].String())
+		out := reflect.New(typ).Elem()
+		errv := reflect.Zero(errorType)
+		if err != nil {
+			errv = reflect.ValueOf(&err).Elem()
+		} else {
+			out.Set(reflect.ValueOf(v).Convert(typ))
+		}
+		return []reflect.Value{out, errv}
+	}).Interface(), true
+}
+
+// kebabCase converts a Go identifier like DryRun into dry-run.
+func kebabCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// start a new word unless inside of an acronym like the ID in UserID.
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
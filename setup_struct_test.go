@@ -0,0 +1,114 @@
+package clingy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zeebo/assert"
+
+	"github.com/zeebo/clingy"
+)
+
+type structCommand struct {
+	Verbose bool          `clingy:"flag,short=v,env=VERBOSE" desc:"prints more"`
+	Count   int           `clingy:"flag,name=num" desc:"number of times"`
+	Timeout time.Duration `clingy:"flag,advanced" desc:"how long to wait"`
+	Mode    string        `clingy:"flag,required,enum=fast|slow" desc:"how to run"`
+	Tags    []string      `clingy:"flag,short=t" desc:"tags to apply"`
+	Limit   *uint16       `clingy:"flag" desc:"an optional limit"`
+	DryRun  bool          `clingy:"flag" desc:"does nothing"`
+	Source  string        `clingy:"arg" desc:"where to read"`
+	Ratio   *float64      `clingy:"arg" desc:"an optional ratio"`
+
+	ignored string
+}
+
+func (c *structCommand) Setup(params clingy.Parameters)    { clingy.SetupStruct(params, c) }
+func (c *structCommand) Execute(ctx context.Context) error { return nil }
+
+func TestSetupStruct(t *testing.T) {
+	{ // values are stored into the fields
+		cmd := &structCommand{Count: 5, ignored: "x"}
+		result := Run(cmd, "-v", "--mode", "slow", "-t", "a", "-t", "b", "--limit", "10", "--dry-run", "src", "0.5")
+		result.AssertValid(t)
+		assert.Equal(t, cmd.Verbose, true)
+		assert.Equal(t, cmd.Count, 5)
+		assert.Equal(t, cmd.Mode, "slow")
+		assert.DeepEqual(t, cmd.Tags, []string{"a", "b"})
+		assert.Equal(t, *cmd.Limit, uint16(10))
+		assert.Equal(t, cmd.DryRun, true)
+		assert.Equal(t, cmd.Source, "src")
+		assert.Equal(t, *cmd.Ratio, 0.5)
+		assert.Equal(t, cmd.ignored, "x")
+	}
+
+	{ // values are parsed into the type of the field
+		cmd := &structCommand{}
+		result := Run(cmd, "--mode", "fast", "--num", "3", "--timeout", "2s", "src")
+		result.AssertValid(t)
+		assert.Equal(t, cmd.Count, 3)
+		assert.Equal(t, cmd.Timeout, 2*time.Second)
+		assert.Nil(t, cmd.Limit)
+		assert.Nil(t, cmd.Ratio)
+	}
+
+	{ // usage describes the fields
+		result := Run(&structCommand{Count: 5}, "-h", "--advanced")
+		result.AssertValid(t)
+		result.AssertStdout(t, `
+			Usage:
+			    testcommand [--verbose] [--num int] [--timeout duration] <--mode string> [--tags string ...] [--limit uint16] [--dry-run] <source> [ratio]
+
+			Arguments:
+			    source    where to read
+			    ratio     an optional ratio
+
+			Flags:
+			    -v, --verbose             prints more (env VERBOSE)
+			        --num int             number of times (default 5)
+			        --timeout duration    how long to wait
+			        --mode string         how to run (required) (one of fast, slow)
+			    -t, --tags string         tags to apply (repeated)
+			        --limit uint16        an optional limit
+			        --dry-run             does nothing
+
+			Global flags:
+			    -h, --help         prints help for the command
+			        --summary      prints a summary of what commands are available
+			        --advanced     when used with -h, prints advanced flags help
+		`)
+	}
+
+	{ // bad tags panic
+		panics := func(v interface{}) (out string) {
+			defer func() { out, _ = recover().(string) }()
+			clingy.SetupStruct(nil, v)
+			return ""
+		}
+		assert.Equal(t, panics(structCommand{}), "SetupStruct requires a pointer to a struct: clingy_test.structCommand")
+		assert.Equal(t, panics(&struct {
+			X int `clingy:"flag,bogus"`
+		}{}), `SetupStruct tag has unknown option: X: "bogus"`)
+		assert.Equal(t, panics(&struct {
+			X map[string]int `clingy:"flag"`
+		}{}), `SetupStruct field has unsupported type: X: map[string]int`)
+		assert.Equal(t, panics(&struct {
+			X string `clingy:"arg,required"`
+		}{}), `SetupStruct required is only supported for flags: X: "arg,required"`)
+	}
+
+	{ // pre-set pointers and slices are defaults
+		limit := uint16(7)
+		cmd := &structCommand{Tags: []string{"x"}, Limit: &limit}
+		result := Run(cmd, "--mode", "fast", "src")
+		result.AssertValid(t)
+		assert.DeepEqual(t, cmd.Tags, []string{"x"})
+		assert.Equal(t, *cmd.Limit, uint16(7))
+
+		cmd = &structCommand{Tags: []string{"x"}}
+		result = Run(cmd, "--mode", "fast", "-t", "y", "src")
+		result.AssertValid(t)
+		assert.DeepEqual(t, cmd.Tags, []string{"y"})
+	}
+}
@@ -0,0 +1,72 @@
+package clingy
+
+import (
+	"strings"
+
+	"github.com/zeebo/errs/v2"
+)
+
+// splitArgs splits the line into arguments like a POSIX shell would, handling
+// single quotes, double quotes and backslash escapes. It does not perform any
+// expansions.
+func splitArgs(line string) (args []string, err error) {
+	var (
+		cur    strings.Builder
+		inArg  bool
+		quote  byte
+		escape bool
+	)
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case escape:
+			cur.WriteByte(c)
+			escape = false
+
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				cur.WriteByte(c)
+			}
+
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else if c == '\\' && i+1 < len(line) && strings.IndexByte(`"\$`+"`", line[i+1]) >= 0 {
+				i++
+				cur.WriteByte(line[i])
+			} else {
+				cur.WriteByte(c)
+			}
+
+		case c == '\\':
+			escape, inArg = true, true
+
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+
+		default:
+			cur.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if escape {
+		return nil, errs.Errorf("trailing backslash")
+	} else if quote != 0 {
+		return nil, errs.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
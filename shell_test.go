@@ -0,0 +1,30 @@
+package clingy
+
+import (
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestSplitArgs(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		args []string
+	}{
+		{"", nil},
+		{"  a  b\tc ", []string{"a", "b", "c"}},
+		{`a 'b c' "d e"`, []string{"a", "b c", "d e"}},
+		{`a\ b "c\"d" 'e\f'`, []string{"a b", `c"d`, `e\f`}},
+		{`"" ''`, []string{"", ""}},
+		{`--flag="some value"`, []string{"--flag=some value"}},
+	} {
+		args, err := splitArgs(tc.line)
+		assert.NoError(t, err)
+		assert.DeepEqual(t, args, tc.args)
+	}
+
+	for _, line := range []string{`a\`, `"a`, `'a`} {
+		_, err := splitArgs(line)
+		assert.Error(t, err)
+	}
+}
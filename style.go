@@ -0,0 +1,72 @@
+package clingy
+
+import (
+	"fmt"
+	"text/template"
+	"unicode/utf8"
+)
+
+// ansiStyles maps the names of styles usable in usage templates to the ANSI
+// escape codes that begin them.
+var ansiStyles = map[string]string{
+	"header": "\x1b[1m",  // bold
+	"name":   "\x1b[36m", // cyan
+	"error":  "\x1b[31m", // red
+	"notes":  "\x1b[2m",  // dim
+}
+
+const ansiReset = "\x1b[0m"
+
+// styler applies ANSI styles to text if it is true.
+type styler bool
+
+func (env *Environment) styler(st *runState) styler {
+	if !env.Color {
+		return false
+	}
+	switch st.color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return styler(env.Getenv("NO_COLOR") == "" && isTerminal(env.Stdout))
+}
+
+// style returns the text of v in the named style. Unknown styles and empty text
+// are returned unchanged.
+func (s styler) style(name string, v interface{}) string {
+	text := fmt.Sprint(v)
+	code, ok := ansiStyles[name]
+	if !bool(s) || !ok || text == "" {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+func (s styler) funcs() template.FuncMap {
+	funcs := template.FuncMap{"style": s.style}
+	for name, fn := range usageFuncs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// visibleLen returns the number of runes in s that are not part of ANSI escape
+// sequences.
+func visibleLen(s string) (n int) {
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '[' {
+			i += 2
+			for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+				i++
+			}
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return n
+}
@@ -1,34 +1,39 @@
 package clingy
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/zeebo/errs/v2"
 )
 
-func (env *Environment) appendUnknownCommandErrorWithSuggestions(st *runState, descs []cmdDesc) {
+func (env *Environment) suggestionDistance() (int, bool) {
 	dist := env.SuggestionsMinEditDistance
 	if dist < 0 {
-		env.appendUnknownCommandError(st)
-		return
+		return 0, false
 	} else if dist == 0 {
 		dist = 2
 	}
+	return dist, true
+}
+
+func (env *Environment) appendUnknownCommandErrorWithSuggestions(st *runState, descs []cmdDesc) {
+	dist, ok := env.suggestionDistance()
+	if !ok {
+		env.appendUnknownCommandError(st)
+		return
+	}
 
 	name, ok, err := st.peekName()
 	if ok {
-		var sbuild strings.Builder
-		fmt.Fprintf(&sbuild, "%q", name)
-		if suggestions := suggestionsFor(name, descs, dist); len(suggestions) > 0 {
-			sbuild.WriteString(". did you mean:")
-			for _, s := range suggestions {
-				sbuild.WriteString("\n\t\t")
-				sbuild.WriteString(s)
-			}
-		}
-
-		st.errors = append(st.errors, errs.Tag("unknown command").Errorf("%s", sbuild.String()))
+		st.errors = append(st.errors, errs.Tag("unknown command").Wrap(&UsageError{
+			Kind:        UnknownCommand,
+			Value:       name,
+			Suggestions: suggestionsFor(name, st, descs, dist),
+			msg:         fmt.Sprintf("%q", name),
+		}))
 	}
 	if err != nil {
 		st.errors = append(st.errors, err)
@@ -38,28 +43,148 @@ func (env *Environment) appendUnknownCommandErrorWithSuggestions(st *runState, d
 func (env *Environment) appendUnknownCommandError(st *runState) {
 	name, ok, err := st.peekName()
 	if ok {
-		st.errors = append(st.errors, errs.Tag("unknown command").Errorf("%q", name))
+		st.errors = append(st.errors, errs.Tag("unknown command").Wrap(&UsageError{
+			Kind:  UnknownCommand,
+			Value: name,
+			msg:   fmt.Sprintf("%q", name),
+		}))
 	}
 	if err != nil {
 		st.errors = append(st.errors, err)
 	}
 }
 
-func suggestionsFor(typedCmd string, cmds []cmdDesc, distance int) []string {
-	suggestions := []string{}
+// maxSuggestions is the maximum number of suggestions included in an error.
+const maxSuggestions = 5
+
+type suggestion struct {
+	text  string
+	score int
+}
+
+func suggestionsFor(typedCmd string, st *runState, cmds []cmdDesc, distance int) []string {
+	var suggestions []suggestion
+
+	// siblings are suggested by name if they are close or typedCmd is a prefix.
 	for _, cmd := range cmds {
-		levenshteinDistance := levenshteinDistance(typedCmd, cmd.name)
-		suggestByLevenshtein := levenshteinDistance <= distance
-		suggestByPrefix := strings.HasPrefix(strings.ToLower(cmd.name), strings.ToLower(typedCmd))
-		if suggestByLevenshtein || suggestByPrefix {
-			suggestions = append(suggestions, cmd.name)
+		score := damerauLevenshteinDistance(typedCmd, cmd.name)
+		if strings.HasPrefix(strings.ToLower(cmd.name), strings.ToLower(typedCmd)) && score > 1 {
+			score = 1
+		}
+		if score <= distance {
+			suggestions = append(suggestions, suggestion{text: cmd.name, score: score})
+		}
+	}
+
+	// commands anywhere else in the tree are suggested by their full path.
+	var walk func(path []string, descs []cmdDesc)
+	walk = func(path []string, descs []cmdDesc) {
+		for _, desc := range descs {
+			dpath := append(path[:len(path):len(path)], desc.name)
+			if !equalStrings(path, st.names) {
+				if score := damerauLevenshteinDistance(typedCmd, desc.name); score <= distance {
+					suggestions = append(suggestions, suggestion{text: strings.Join(dpath, " "), score: score})
+				}
+			}
+			walk(dpath, desc.subcmds)
 		}
 	}
-	return suggestions
+	walk(st.names[:1], st.tree)
+
+	return bestSuggestions(suggestions)
+}
+
+// bestSuggestions returns the text of the suggestions with the lowest scores, in
+// order, keeping the original order of equal scores.
+func bestSuggestions(suggestions []suggestion) []string {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].score < suggestions[j].score
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+
+	out := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		out = append(out, s.text)
+	}
+	return out
 }
 
-// levenshteinDistance compares two strings and returns the Levenshtein Distance between them.
-func levenshteinDistance(a, b string) int {
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// addFlagSuggestions includes suggestions for any unknown flag errors from the
+// flags that are defined for the command and globally.
+func (env *Environment) addFlagSuggestions(st *runState) {
+	dist, ok := env.suggestionDistance()
+	if !ok {
+		return
+	}
+
+	for _, err := range st.errors {
+		var ue *UsageError
+		if errors.As(err, &ue) && ue.Kind == UnknownFlag && ue.Suggestions == nil {
+			ue.Suggestions = flagSuggestionsFor(ue.Value, st, dist)
+		}
+	}
+}
+
+func flagSuggestionsFor(typedFlag string, st *runState, distance int) []string {
+	typed := strings.TrimLeft(typedFlag, "-")
+	if idx := strings.IndexByte(typed, '='); idx >= 0 {
+		typed = typed[:idx]
+	}
+	if typed == "" {
+		return nil
+	}
+
+	// a single character after a single dash can only be a short flag
+	short := len(typed) == 1 && !strings.HasPrefix(typedFlag, "--")
+
+	// the flag that was typed is never suggested, such as when it was specified
+	// more than once or after the arguments of a passthrough command.
+	var suggestions []suggestion
+	check := func(p *param) {
+		if p == nil || p.hidden {
+			return
+		}
+		if short {
+			if p.short != 0 && string(p.short) != typed && strings.EqualFold(typed, string(p.short)) {
+				suggestions = append(suggestions, suggestion{text: "-" + string(p.short), score: 1})
+			}
+			return
+		}
+		if p.name == typed {
+			return
+		}
+		score := damerauLevenshteinDistance(typed, p.name)
+		if strings.HasPrefix(strings.ToLower(p.name), strings.ToLower(typed)) && score > 1 {
+			score = 1
+		}
+		if score <= distance {
+			suggestions = append(suggestions, suggestion{text: "--" + p.name, score: score})
+		}
+	}
+	st.flags.params(check)
+	st.gflags.params(check)
+
+	return bestSuggestions(suggestions)
+}
+
+// damerauLevenshteinDistance compares two strings and returns the Damerau-Levenshtein
+// distance between them, where adjacent transpositions count as a single edit. Each
+// substring may be edited at most once.
+func damerauLevenshteinDistance(a, b string) int {
 	a = strings.ToLower(a)
 	b = strings.ToLower(b)
 
@@ -87,6 +212,9 @@ func levenshteinDistance(a, b string) int {
 				}
 				d[i][j] = min + 1
 			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
 		}
 	}
 	return d[len(a)][len(b)]
@@ -2,7 +2,7 @@ package clingy
 
 import "testing"
 
-func Test_levenshteinDistance(t *testing.T) {
+func Test_damerauLevenshteinDistance(t *testing.T) {
 	type args struct {
 		a string
 		b string
@@ -60,10 +60,26 @@ func Test_levenshteinDistance(t *testing.T) {
 			},
 			want: 3,
 		},
+		{
+			name: "transposition",
+			args: args{
+				a: "mvoe",
+				b: "move",
+			},
+			want: 1,
+		},
+		{
+			name: "transposition and substitution",
+			args: args{
+				a: "cpoy",
+				b: "cops",
+			},
+			want: 2,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := levenshteinDistance(tt.args.a, tt.args.b); got != tt.want {
+			if got := damerauLevenshteinDistance(tt.args.a, tt.args.b); got != tt.want {
 				t.Errorf("EditDistance() = %v, want %v", got, tt.want)
 			}
 		})
@@ -12,16 +12,26 @@ func (env *Environment) printSummary(ctx context.Context, st *runState, desc cmd
 	tw := tabwriter.NewWriter(env.Stdout, 4, 4, 4, ' ', 0)
 	defer tw.Flush()
 
-	fmt.Fprintln(tw, "Available commands:")
-	printSubcommandsRecursive(ctx, tw, st.names, desc)
+	sty := env.styler(st)
+	fmt.Fprintln(tw, sty.style("header", "Available commands:"))
+	printSubcommandsRecursive(ctx, tw, sty, st.examples, st.names, desc)
 }
 
-func printSubcommandsRecursive(ctx context.Context, w io.Writer, name []string, desc cmdDesc) {
+func printSubcommandsRecursive(ctx context.Context, w io.Writer, sty styler, examples bool, name []string, desc cmdDesc) {
 	for _, desc := range desc.subcmds {
 		dname := append(name, desc.name)
-		if desc.cmd != nil {
-			fmt.Fprintf(w, "\t%s\t%s\n", strings.Join(dname, " "), desc.short)
+		if desc.hasCmd() {
+			fmt.Fprintf(w, "\t%s\t%s\n", sty.style("name", strings.Join(dname, " ")), desc.short)
+			if examples {
+				for _, ex := range desc.examples {
+					if ex.desc == "" {
+						fmt.Fprintf(w, "\t    %s\n", exampleLine(dname, ex))
+					} else {
+						fmt.Fprintf(w, "\t    %s\t%s\n", exampleLine(dname, ex), ex.desc)
+					}
+				}
+			}
 		}
-		printSubcommandsRecursive(ctx, w, dname, desc)
+		printSubcommandsRecursive(ctx, w, sty, examples, dname, desc)
 	}
 }
@@ -0,0 +1,35 @@
+package clingy
+
+type fder interface{ Fd() uintptr }
+
+// isTerminal returns true if x is backed by a file descriptor for a terminal.
+func isTerminal(x interface{}) bool {
+	f, ok := x.(fder)
+	return ok && isTerminalFd(f.Fd())
+}
+
+// terminalWidth returns the width of the terminal backing x, or 0 if unknown.
+func terminalWidth(x interface{}) int {
+	if f, ok := x.(fder); ok {
+		return terminalWidthFd(f.Fd())
+	}
+	return 0
+}
+
+// disableEcho turns off echoing of input on the terminal backing x, returning
+// a function to restore it. It returns false if echoing could not be disabled.
+func disableEcho(x interface{}) (restore func(), ok bool) {
+	if f, ok := x.(fder); ok {
+		return disableEchoFd(f.Fd())
+	}
+	return nil, false
+}
+
+// makeRaw puts the terminal backing x into raw mode, returning a function to
+// restore it. It returns false if the terminal could not be put into raw mode.
+func makeRaw(x interface{}) (restore func(), ok bool) {
+	if f, ok := x.(fder); ok {
+		return makeRawFd(f.Fd())
+	}
+	return nil, false
+}
@@ -0,0 +1,36 @@
+package clingy
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// openPty returns the controller and terminal ends of a new pseudo terminal,
+// skipping the test if one cannot be opened.
+func openPty(t *testing.T) (ptm, pts *os.File) {
+	t.Helper()
+
+	ptm, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("unable to open pseudo terminal:", err)
+	}
+	t.Cleanup(func() { _ = ptm.Close() })
+
+	var n, unlock uint32
+	if err := ioctl(ptm.Fd(), syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		t.Skip("unable to unlock pseudo terminal:", err)
+	}
+	if err := ioctl(ptm.Fd(), syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		t.Skip("unable to find pseudo terminal:", err)
+	}
+	pts, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skip("unable to open pseudo terminal:", err)
+	}
+	t.Cleanup(func() { _ = pts.Close() })
+
+	return ptm, pts
+}
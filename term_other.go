@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package clingy
+
+func isTerminalFd(fd uintptr) bool                       { return false }
+func terminalWidthFd(fd uintptr) int                     { return 0 }
+func disableEchoFd(fd uintptr) (restore func(), ok bool) { return nil, false }
+func makeRawFd(fd uintptr) (restore func(), ok bool)     { return nil, false }
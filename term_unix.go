@@ -0,0 +1,60 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package clingy
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func ioctl(fd, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func isTerminalFd(fd uintptr) bool {
+	var t syscall.Termios
+	return ioctl(fd, ioctlGetTermios, unsafe.Pointer(&t)) == nil
+}
+
+func terminalWidthFd(fd uintptr) int {
+	var ws struct{ row, col, xpixel, ypixel uint16 }
+	if ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)) != nil {
+		return 0
+	}
+	return int(ws.col)
+}
+
+func disableEchoFd(fd uintptr) (restore func(), ok bool) {
+	var old syscall.Termios
+	if ioctl(fd, ioctlGetTermios, unsafe.Pointer(&old)) != nil {
+		return nil, false
+	}
+	t := old
+	t.Lflag &^= syscall.ECHO
+	t.Lflag |= syscall.ICANON | syscall.ISIG
+	if ioctl(fd, ioctlSetTermios, unsafe.Pointer(&t)) != nil {
+		return nil, false
+	}
+	return func() { _ = ioctl(fd, ioctlSetTermios, unsafe.Pointer(&old)) }, true
+}
+
+func makeRawFd(fd uintptr) (restore func(), ok bool) {
+	var old syscall.Termios
+	if ioctl(fd, ioctlGetTermios, unsafe.Pointer(&old)) != nil {
+		return nil, false
+	}
+	t := old
+	t.Iflag &^= syscall.ICRNL | syscall.IXON
+	t.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	t.Cc[syscall.VMIN] = 1
+	t.Cc[syscall.VTIME] = 0
+	if ioctl(fd, ioctlSetTermios, unsafe.Pointer(&t)) != nil {
+		return nil, false
+	}
+	return func() { _ = ioctl(fd, ioctlSetTermios, unsafe.Pointer(&old)) }, true
+}
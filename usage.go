@@ -1,42 +1,150 @@
 package clingy
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 )
 
 func (env *Environment) printUsage(ctx context.Context, st *runState, desc cmdDesc) {
-	tw := tabwriter.NewWriter(env.Stdout, 4, 4, 4, ' ', 0)
-	defer tw.Flush()
-
-	printErrors(ctx, tw, st.errors)
-	printUsagePrefix(ctx, tw, st, desc)
-	printSubcommands(ctx, tw, st, desc.subcmds)
-	printArguments(ctx, tw, st.pos)
-	printFlags(ctx, tw, st)
-	printGlobalFlags(ctx, tw, st)
-	printUsageSuffix(ctx, tw, st, len(desc.subcmds) > 0)
+	text := env.UsageTemplate
+	if text == "" {
+		text = DefaultUsageTemplate
+	}
+	tmpl := template.Must(template.New("usage").Funcs(env.styler(st).funcs()).Parse(text))
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newUsageData(st, desc)); err != nil {
+		panic(fmt.Sprintf("unable to execute usage template: %v", err))
+	}
+	_, _ = io.WriteString(env.Stdout, alignColumns(buf.String(), env.usageWidth()))
 }
 
-func printErrors(ctx context.Context, w io.Writer, errs []error) {
-	if len(errs) == 0 {
-		return
+// usageWidth returns the width that usage should be wrapped to, or 0 if it
+// should not be wrapped.
+func (env *Environment) usageWidth() int {
+	if env.UsageWidth != 0 {
+		if env.UsageWidth < 0 {
+			return 0
+		}
+		return env.UsageWidth
 	}
+	if width := terminalWidth(env.Stdout); width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(env.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 0
+}
+
+// minWrapWidth is the narrowest that the last column is wrapped to. If there
+// is less room than this, lines are left to run long.
+const minWrapWidth = 20
 
-	fmt.Fprintln(w, "Errors:")
-	for _, err := range errs {
-		fmt.Fprintf(w, "\t%s\n", err)
+// alignColumns aligns the tab separated cells in text and, if width is positive,
+// wraps the last cell of any line that is too long so that the continuation lines
+// are indented to the start of the cell.
+func alignColumns(text string, width int) string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 4, 4, 4, ' ', 0)
+	_, _ = tw.Write([]byte(text))
+	_ = tw.Flush()
+	if width <= 0 {
+		return buf.String()
 	}
-	fmt.Fprintln(w)
+
+	in := strings.Split(text, "\n")
+	out := strings.Split(buf.String(), "\n")
+	if len(in) != len(out) {
+		return buf.String()
+	}
+
+	for i, line := range out {
+		tab := strings.LastIndexByte(in[i], '\t')
+		if tab == -1 || visibleLen(line) <= width {
+			continue
+		}
+		tail := in[i][tab+1:]
+		prefix := line[:len(line)-len(tail)]
+		indent := visibleLen(prefix)
+		if width-indent < minWrapWidth {
+			continue
+		}
+		out[i] = prefix + indentText(strings.Repeat(" ", indent), wrapText(width-indent, tail))[indent:]
+	}
+	return strings.Join(out, "\n")
 }
 
-func printUsagePrefix(ctx context.Context, w io.Writer, st *runState, desc cmdDesc) {
-	fmt.Fprintf(w, "Usage:\n")
-	fmt.Fprintf(w, "\t%s", st.name())
+// printUsageErrors reports the errors in the run state to the OnUsageError hook
+// and prints them along with the usage.
+func (env *Environment) printUsageErrors(ctx context.Context, st *runState, desc cmdDesc) {
+	env.addFlagSuggestions(st)
+	if env.OnUsageError != nil {
+		for _, err := range st.errors {
+			var ue *UsageError
+			if errors.As(err, &ue) {
+				env.OnUsageError(ue)
+			}
+		}
+	}
+	env.printUsage(ctx, st, desc)
+}
+
+func newUsageData(st *runState, desc cmdDesc) *UsageData {
+	data := &UsageData{
+		Name:     st.name(),
+		Usage:    usageLine(st, desc),
+		Short:    desc.short,
+		Long:     desc.long,
+		Errors:   st.errors,
+		Advanced: st.advanced,
+	}
+	for _, ex := range desc.examples {
+		data.Examples = append(data.Examples, UsageExample{
+			Line: exampleLine(st.names, ex),
+			Desc: ex.desc,
+		})
+	}
+	for _, desc := range desc.subcmds {
+		data.Commands = append(data.Commands, UsageCommand{
+			Name:  desc.name,
+			Short: desc.short,
+		})
+	}
+	st.pos.params(func(p *param) {
+		data.Arguments = append(data.Arguments, newUsageParam(p))
+	})
+	visible := func(out *[]UsageParam) func(p *param) {
+		return func(p *param) {
+			if p == nil || (!p.hidden && (st.advanced || !p.adv)) {
+				*out = append(*out, newUsageParam(p))
+			}
+		}
+	}
+	st.flags.params(visible(&data.Flags))
+	st.gflags.params(visible(&data.GlobalFlags))
+	return data
+}
+
+func exampleLine(names []string, ex cmdExample) string {
+	line := strings.Join(names, " ")
+	if ex.args != "" {
+		line += " " + ex.args
+	}
+	return line
+}
+
+func usageLine(st *runState, desc cmdDesc) string {
+	var b strings.Builder
+	b.WriteString(st.name())
 
 	padLeft := func(s string) string {
 		if s != "" {
@@ -58,103 +166,35 @@ func printUsagePrefix(ctx context.Context, w io.Writer, st *runState, desc cmdDe
 			return
 		}
 		if p.rep {
-			fmt.Fprintf(w, " %c--%s%s ...%c", chars[0], p.name, padLeft(p.flagType()), chars[1])
+			fmt.Fprintf(&b, " %c--%s%s ...%c", chars[0], p.name, padLeft(p.flagType()), chars[1])
 		} else {
-			fmt.Fprintf(w, " %c--%s%s%c", chars[0], p.name, padLeft(p.flagType()), chars[1])
+			fmt.Fprintf(&b, " %c--%s%s%c", chars[0], p.name, padLeft(p.flagType()), chars[1])
 		}
 	})
 	if !st.advanced && st.flags.getCount()-req > 0 {
-		fmt.Fprintf(w, " [flags]")
+		fmt.Fprintf(&b, " [flags]")
 	}
 
 	optionals := 0
 	st.pos.params(func(p *param) {
 		switch {
 		case p.rep:
-			fmt.Fprintf(w, " [%s ...]", p.name)
+			fmt.Fprintf(&b, " [%s ...]", p.name)
 		case p.opt:
-			fmt.Fprintf(w, " [%s", p.name)
+			fmt.Fprintf(&b, " [%s", p.name)
 			optionals++
 		default:
-			fmt.Fprintf(w, " <%s>", p.name)
+			fmt.Fprintf(&b, " <%s>", p.name)
 		}
 	})
 	for i := 0; i < optionals; i++ {
-		fmt.Fprint(w, "]")
+		fmt.Fprint(&b, "]")
 	}
 
 	if len(desc.subcmds) > 0 {
-		fmt.Fprint(w, " [command]")
+		fmt.Fprint(&b, " [command]")
 	}
-	fmt.Fprintln(w)
-
-	if len(desc.short) > 0 {
-		fmt.Fprintln(w)
-		fmt.Fprintln(w, "\t"+desc.short)
-	}
-	if len(desc.long) > 0 {
-		fmt.Fprintln(w)
-		fmt.Fprintln(w, "\t"+strings.Join(strings.Split(desc.long, "\n"), "\n\t"))
-	}
-}
-
-func printSubcommands(ctx context.Context, w io.Writer, st *runState, descs []cmdDesc) {
-	hp := newHeaderPrinter(w, "Available commands:")
-	for _, desc := range descs {
-		fmt.Fprintf(hp, "\t%s\t%s\n", desc.name, desc.short)
-	}
-}
-
-func printArguments(ctx context.Context, w io.Writer, pos *paramsPos) {
-	hp := newHeaderPrinter(w, "Arguments:")
-	pos.params(func(p *param) {
-		fmt.Fprintf(hp, "\t%s\t%s\n", p.name, p.desc)
-	})
-}
-
-func printFlags(ctx context.Context, w io.Writer, st *runState) {
-	hp := newHeaderPrinter(w, "Flags:")
-	st.flags.params(func(p *param) {
-		if p == nil || (!p.hidden && (st.advanced || !p.adv)) {
-			printFlag(ctx, hp, p)
-		}
-	})
-}
-
-func printGlobalFlags(ctx context.Context, w io.Writer, st *runState) {
-	hp := newHeaderPrinter(w, "Global flags:")
-	st.gflags.params(func(p *param) {
-		if p == nil || (!p.hidden && (st.advanced || !p.adv)) {
-			printFlag(ctx, hp, p)
-		}
-	})
-}
-
-func printFlag(ctx context.Context, w io.Writer, p *param) {
-	if p == nil {
-		fmt.Fprintln(w)
-		return
-	}
-	fmt.Fprint(w, "\t")
-	if p.short != 0 {
-		fmt.Fprintf(w, "-%c, ", p.short)
-	} else {
-		fmt.Fprint(w, "    ")
-	}
-	fmt.Fprintf(w, "--%s %s\t%s", p.name, p.flagType(), p.desc)
-	if p.def == Required {
-		fmt.Fprintf(w, " (required)")
-	}
-	if p.rep {
-		fmt.Fprintf(w, " (repeated)")
-	}
-	if p.getenv != "" {
-		fmt.Fprintf(w, " (env %s)", p.getenv)
-	}
-	if !isZero(p.def) {
-		fmt.Fprintf(w, " (default %v)", stringify(deref(p.def)))
-	}
-	fmt.Fprintln(w)
+	return b.String()
 }
 
 func stringify(x interface{}) string {
@@ -171,12 +211,6 @@ func deref(x interface{}) interface{} {
 	return x
 }
 
-func printUsageSuffix(ctx context.Context, w io.Writer, st *runState, subcmds bool) {
-	if subcmds {
-		fmt.Fprintf(w, "\nUse \"%s [command] --help\" for more information about a command.\n", st.name())
-	}
-}
-
 func isZero(x interface{}) bool {
 	rv := reflect.ValueOf(x)
 	return !rv.IsValid() || rv.IsZero() || (rv.Kind() == reflect.Slice && rv.Len() == 0)
@@ -0,0 +1,157 @@
+package clingy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zeebo/errs/v2"
+)
+
+// UsageErrorKind describes why parsing a command line failed.
+type UsageErrorKind int
+
+const (
+	// UnknownCommand means a command name did not match any defined command.
+	UnknownCommand UsageErrorKind = iota + 1
+
+	// UnknownFlag means an argument looked like a flag that was not defined.
+	UnknownFlag
+
+	// UnknownArguments means there were more positional arguments than defined.
+	UnknownArguments
+
+	// MissingValue means a flag that requires a value was specified without one.
+	MissingValue
+
+	// MissingRequired means a required flag or argument was not specified.
+	MissingRequired
+
+	// InvalidValue means the value for a flag or argument could not be read or
+	// was rejected by a Transform function.
+	InvalidValue
+)
+
+// String returns a description of the kind.
+func (k UsageErrorKind) String() string {
+	switch k {
+	case UnknownCommand:
+		return "unknown command"
+	case UnknownFlag:
+		return "unknown flag"
+	case UnknownArguments:
+		return "unknown arguments"
+	case MissingValue:
+		return "missing value"
+	case MissingRequired:
+		return "missing required"
+	case InvalidValue:
+		return "invalid value"
+	default:
+		return fmt.Sprintf("UsageErrorKind(%d)", int(k))
+	}
+}
+
+// UsageError describes a failure to parse the command line. It is passed to the
+// Environment's OnUsageError hook and can be found with errors.As.
+type UsageError struct {
+	// Kind is why the parsing failed.
+	Kind UsageErrorKind
+
+	// Param is the name of the flag or argument involved, if any.
+	Param string
+
+	// Value is the raw value that caused the failure, if any. For an unknown
+	// command, it is the command name. For unknown flags, it is the argument as
+	// it was specified. For unknown arguments, it is the first unknown argument.
+	Value string
+
+	// Err is the underlying error, such as the one returned by a Transform
+	// function, if any.
+	Err error
+
+	// Suggestions contains similar names that may have been intended, if any.
+	Suggestions []string
+
+	// Secret is true if the flag or argument has the Secret option. The Value is
+	// redacted, the message only names the Param and Kind, and Err is nil.
+	Secret bool
+
+	msg string
+}
+
+// Error returns the message printed in the usage output for the error.
+func (e *UsageError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return e.msg
+	}
+
+	var sbuild strings.Builder
+	sbuild.WriteString(e.msg)
+	sbuild.WriteString(". did you mean:")
+	for _, s := range e.Suggestions {
+		sbuild.WriteString("\n\t\t")
+		sbuild.WriteString(s)
+	}
+	return sbuild.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *UsageError) Unwrap() error { return e.Err }
+
+// DefinitionError describes a mistake in how a flag or argument was defined,
+// such as defining the same flag twice. Such mistakes cause a panic unless the
+// Environment's CollectDefinitionErrors is set, in which case they are printed
+// with usage and returned from Run.
+type DefinitionError struct {
+	// Param is the name of the flag or argument involved, if any.
+	Param string
+
+	msg string
+}
+
+// Error returns the message printed in the usage output for the error.
+func (e *DefinitionError) Error() string { return e.msg }
+
+// redacted replaces the values of Secret flags and arguments.
+type redacted string
+
+// redactedValue is used in place of the values of Secret flags and arguments.
+const redactedValue redacted = "<redacted>"
+
+// redactError replaces the usage error in err with one that does not contain
+// the value. The message is built from only the Param and Kind, and the
+// underlying error is removed, because they may contain any part of the value.
+func redactError(err error) error {
+	var ue *UsageError
+	if !errors.As(err, &ue) {
+		return err
+	}
+
+	ue.Secret = true
+	ue.msg = fmt.Sprintf("%s: %s", ue.Param, ue.Kind)
+	if ue.Value != "" {
+		ue.msg += " " + string(redactedValue)
+		ue.Value = string(redactedValue)
+	}
+	ue.Err = nil
+	return err
+}
+
+func invalidValue(param, value string, err error, format string, args ...interface{}) error {
+	return errs.Wrap(&UsageError{
+		Kind:  InvalidValue,
+		Param: param,
+		Value: value,
+		Err:   err,
+		msg:   fmt.Sprintf(format, args...),
+	})
+}
+
+func missingRequired(param, format string, args ...interface{}) error {
+	return errs.Wrap(&UsageError{
+		Kind:  MissingRequired,
+		Param: param,
+		msg:   fmt.Sprintf(format, args...),
+	})
+}
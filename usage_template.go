@@ -0,0 +1,238 @@
+package clingy
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultUsageTemplate is the text/template used to print usage information if
+// the Environment does not specify one. The template is executed with a *UsageData
+// and its output is aligned on tabs into columns.
+//
+// In addition to the builtin functions, templates may use
+//
+//	indent prefix text    inserts prefix at the start of every line of text
+//	wrap width text       wraps the words of text into lines of at most width
+//	pad width text        pads text with spaces on the right to be width long
+//	join sep list         joins the list of strings with sep
+//	style name value      applies the named style to the value when colors are
+//	                      enabled: one of "header", "name", "error" or "notes"
+const DefaultUsageTemplate = `
+{{- if .Errors}}{{style "header" "Errors:"}}
+{{range .Errors}}	{{style "error" .}}
+{{end}}
+{{end}}{{style "header" "Usage:"}}
+	{{.Usage}}
+{{if .Short}}
+	{{.Short}}
+{{end}}{{if .Long}}
+{{indent "\t" .Long}}
+{{end}}{{if .Commands}}
+{{style "header" "Available commands:"}}
+{{range .Commands}}	{{style "name" .Name}}	{{.Short}}
+{{end}}{{end}}{{if .Arguments}}
+{{style "header" "Arguments:"}}
+{{range .Arguments}}	{{style "name" .Name}}	{{.Desc}}{{style "notes" .EnvNote}}
+{{end}}{{end}}{{if .Flags}}
+{{style "header" "Flags:"}}
+{{range .Flags}}{{template "flag" .}}{{end}}{{end}}{{if .GlobalFlags}}
+{{style "header" "Global flags:"}}
+{{range .GlobalFlags}}{{template "flag" .}}{{end}}{{end}}{{if .Examples}}
+{{style "header" "Examples:"}}
+{{range $i, $ex := .Examples}}{{if $i}}
+{{end}}	{{$ex.Line}}
+{{if $ex.Desc}}	    {{$ex.Desc}}
+{{end}}{{end}}{{end}}{{if .Commands}}
+Use "{{.Name}} [command] --help" for more information about a command.
+{{end}}
+{{- define "flag"}}{{if .Break}}
+{{else}}	{{style "name" .Spec}}	{{.Desc}}{{style "notes" .Notes}}
+{{end}}{{end}}`
+
+// UsageData is the information available to usage templates.
+type UsageData struct {
+	// Name is the full name of the command, e.g. "tool files copy".
+	Name string
+
+	// Usage is the synopsis of how to invoke the command.
+	Usage string
+
+	// Short and Long are the parts of the description of the command.
+	Short string
+	Long  string
+
+	// Errors contains any errors that happened parsing the command line.
+	Errors []error
+
+	// Commands contains the subcommands of the command.
+	Commands []UsageCommand
+
+	// Arguments contains the positional arguments of the command.
+	Arguments []UsageParam
+
+	// Flags and GlobalFlags contain the flags that are visible, taking into
+	// account if the --advanced flag was specified.
+	Flags       []UsageParam
+	GlobalFlags []UsageParam
+
+	// Examples contains the example invocations of the command.
+	Examples []UsageExample
+
+	// Advanced is true if the --advanced flag was specified.
+	Advanced bool
+}
+
+// UsageExample describes an example invocation of a command in usage templates.
+type UsageExample struct {
+	// Line is the full command line, including the name of the command.
+	Line string
+
+	// Desc explains what the example does.
+	Desc string
+}
+
+// UsageCommand describes a subcommand in usage templates.
+type UsageCommand struct {
+	Name  string
+	Short string
+}
+
+// UsageParam describes a flag or argument in usage templates.
+type UsageParam struct {
+	// Break is true if the entry is a line break added with Break. All of the
+	// other fields are empty.
+	Break bool
+
+	Name     string
+	Desc     string
+	Type     string      // the type shown in usage, if any
+	Short    string      // the short name of the flag, if any
+	Env      string      // the environment variable for the flag or argument, if any
+	EnvSep   string      // the separator of multiple values in the environment variable, if any
+	Enum     []string    // the allowed values, if restricted
+	Default  interface{} // the default value of the flag, if any
+	Required bool
+	Optional bool
+	Repeated bool
+	Advanced bool
+	Secret   bool // if set, Default is redacted
+}
+
+func newUsageParam(p *param) UsageParam {
+	if p == nil {
+		return UsageParam{Break: true}
+	}
+	up := UsageParam{
+		Name:     p.name,
+		Desc:     p.desc,
+		Type:     p.flagType(),
+		Env:      p.getenv,
+		EnvSep:   p.envsep,
+		Enum:     p.enum,
+		Required: p.def == Required,
+		Optional: p.opt,
+		Repeated: p.rep,
+		Advanced: p.adv,
+		Secret:   p.secret,
+	}
+	if p.short != 0 {
+		up.Short = string(p.short)
+	}
+	if !up.Required {
+		up.Default = p.def
+		if p.secret && !isZero(p.def) {
+			up.Default = redactedValue
+		}
+	}
+	return up
+}
+
+// Spec returns how the flag is specified, e.g. "-v, --verbose" or "    --name string".
+func (p UsageParam) Spec() string {
+	if p.Break {
+		return ""
+	}
+	short := "    "
+	if p.Short != "" {
+		short = "-" + p.Short + ", "
+	}
+	return fmt.Sprintf("%s--%s %s", short, p.Name, p.Type)
+}
+
+// Notes returns the parenthesized details that follow the description of a flag,
+// such as whether it is required or what its default is, with a leading space.
+func (p UsageParam) Notes() string {
+	var b strings.Builder
+	if p.Required {
+		b.WriteString(" (required)")
+	}
+	if p.Repeated {
+		b.WriteString(" (repeated)")
+	}
+	if len(p.Enum) > 0 {
+		fmt.Fprintf(&b, " (one of %s)", strings.Join(p.Enum, ", "))
+	}
+	b.WriteString(p.EnvNote())
+	if !isZero(p.Default) {
+		fmt.Fprintf(&b, " (default %v)", stringify(deref(p.Default)))
+	}
+	return b.String()
+}
+
+// EnvNote returns the note describing the environment variable of the parameter,
+// or an empty string if it has none.
+func (p UsageParam) EnvNote() string {
+	switch {
+	case p.Env == "":
+		return ""
+	case p.EnvSep != "" && p.Repeated:
+		return fmt.Sprintf(" (env %s, separated by %q)", p.Env, p.EnvSep)
+	default:
+		return fmt.Sprintf(" (env %s)", p.Env)
+	}
+}
+
+var usageFuncs = template.FuncMap{
+	"indent": indentText,
+	"wrap":   wrapText,
+	"pad":    padText,
+	"join":   func(sep string, list []string) string { return strings.Join(list, sep) },
+}
+
+func indentText(prefix, text string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+func padText(width int, text string) string {
+	if n := width - visibleLen(text); n > 0 {
+		return text + strings.Repeat(" ", n)
+	}
+	return text
+}
+
+func wrapText(width int, text string) string {
+	if width <= 0 {
+		return text
+	}
+
+	var b strings.Builder
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		n := 0
+		for _, word := range strings.Fields(line) {
+			if n > 0 && n+1+visibleLen(word) > width {
+				b.WriteByte('\n')
+				n = 0
+			} else if n > 0 {
+				b.WriteByte(' ')
+				n++
+			}
+			b.WriteString(word)
+			n += visibleLen(word)
+		}
+	}
+	return b.String()
+}
@@ -217,6 +217,7 @@ func TestUsage_DistanceSuggestions(t *testing.T) {
 		result.AssertStdout(t, `
 			Errors:
 			    unknown command: "amd4". did you mean:
+			        testcommand grp1 cmd4
 			        cmd1
 			        cmd2
 
@@ -316,3 +317,343 @@ func TestUsage_DistanceSuggestions(t *testing.T) {
 		`)
 	}
 }
+
+func TestUsage_FlagSuggestions(t *testing.T) {
+	root := &funcCommand{
+		SetupFn: func(params clingy.Parameters) {
+			params.Flag("verbose", "verbose output", false, clingy.Boolean, clingy.Short('v'), clingy.Transform(strconv.ParseBool))
+			params.Flag("verify", "verify output", false, clingy.Boolean, clingy.Hidden, clingy.Transform(strconv.ParseBool))
+		},
+		ExecuteFn: func(ctx context.Context) error { return nil },
+	}
+
+	{
+		result := Run(root, "--verbos")
+		result.AssertStdout(t, `
+			Errors:
+			    argument error: unknown flag: "--verbos". did you mean:
+			        --verbose
+
+			Usage:
+			    testcommand [flags]
+
+			Flags:
+			    -v, --verbose     verbose output
+
+			Global flags:
+			    -h, --help         prints help for the command
+			        --summary      prints a summary of what commands are available
+			        --advanced     when used with -h, prints advanced flags help
+		`)
+	}
+
+	{
+		result := Run(root, "-V", "--summ=true")
+		result.AssertStdoutContains(t, `
+    argument error: unknown flag: "-V". did you mean:
+        -v
+`)
+	}
+
+	{ // the flag that was typed is not suggested
+		result := Run(root, "--verbose", "-v")
+		result.AssertStdoutContains(t, `argument error: unknown flag: "-v"
+`)
+	}
+
+	{ // suggestions are sorted by distance and limited
+		result := Run(&funcCommand{
+			SetupFn: func(params clingy.Parameters) {
+				for _, name := range []string{"optic", "option-a", "option-b", "option-c", "options", "potion", "opts"} {
+					params.Flag(name, "an option", "")
+				}
+			},
+			ExecuteFn: func(ctx context.Context) error { return nil },
+		}, "--option")
+		result.AssertStdoutContains(t, `
+    argument error: unknown flag: "--option". did you mean:
+        --option-a
+        --option-b
+        --option-c
+        --options
+        --potion
+
+`)
+	}
+
+	{
+		env := Env("testcommand", root, "--verbos")
+		env.SuggestionsMinEditDistance = -1
+
+		result := Capture(env, nil)
+		result.AssertStdoutContains(t, `argument error: unknown flag: "--verbos"
+`)
+	}
+}
+
+func TestUsage_TreeSuggestions(t *testing.T) {
+	cmds := func(cmds clingy.Commands) {
+		cmds.New("move", "m", nil)
+		cmds.Group("files", "f", func() {
+			cmds.New("delete", "d", nil)
+			cmds.New("list", "l", nil)
+		})
+		cmds.Group("dirs", "d", func() {
+			cmds.New("delete", "d", nil)
+		})
+	}
+
+	{ // transpositions count as a single edit
+		result := Capture(Env("tool", nil, "mvoe"), cmds)
+		result.AssertStdoutContains(t, `
+    unknown command: "mvoe". did you mean:
+        move
+
+`)
+	}
+
+	{ // commands in other groups are suggested by their full path
+		result := Capture(Env("tool", nil, "delete"), cmds)
+		result.AssertStdoutContains(t, `
+    unknown command: "delete". did you mean:
+        tool files delete
+        tool dirs delete
+
+`)
+	}
+
+	{ // siblings are not repeated with their full path
+		result := Capture(Env("tool", nil, "files", "delet"), cmds)
+		result.AssertStdoutContains(t, `
+    unknown command: "delet". did you mean:
+        delete
+        tool dirs delete
+
+`)
+	}
+}
+
+func TestUsage_Template(t *testing.T) {
+	env := Env("testcommand", nil, "cmd", "-h")
+	env.UsageTemplate = `{{.Name}}: {{.Short}}
+{{range .Arguments}}	<{{.Name}}>	{{.Desc}}
+{{end}}{{range .Flags}}	{{.Spec}}	{{wrap 10 .Desc}}{{.Notes}}
+{{end}}
+Examples:
+	{{.Name}} foo
+`
+
+	result := Capture(env, func(cmds clingy.Commands) {
+		cmds.New("cmd", "some command", &funcCommand{
+			SetupFn: func(params clingy.Parameters) {
+				params.Flag("count", "number of times to do the thing", 1, clingy.Transform(strconv.Atoi))
+				params.Arg("name", "the name")
+			},
+			ExecuteFn: func(ctx context.Context) error { return nil },
+		})
+	})
+	result.AssertValid(t)
+	result.AssertStdout(t, `
+		testcommand cmd: some command
+		    <name>             the name
+		        --count int    number of
+		times to
+		do the
+		thing (default 1)
+
+		Examples:
+		    testcommand cmd foo
+	`)
+}
+
+func TestUsage_Wrapping(t *testing.T) {
+	root := &funcCommand{
+		SetupFn: func(params clingy.Parameters) {
+			params.Flag("count", "number of times to repeat the operation before giving up", 1,
+				clingy.Transform(strconv.Atoi))
+			params.Arg("name", "the name of the thing to operate on")
+		},
+		ExecuteFn: func(ctx context.Context) error { return nil },
+	}
+
+	expected := `
+		Usage:
+		    testcommand [flags] <name>
+
+		Arguments:
+		    name    the name of the thing to operate on
+
+		Flags:
+		        --count int    number of times to repeat
+		                       the operation before giving
+		                       up (default 1)
+
+		Global flags:
+		    -h, --help         prints help for the command
+		        --summary      prints a summary of what
+		                       commands are available
+		        --advanced     when used with -h, prints
+		                       advanced flags help
+	`
+
+	{ // explicit width
+		env := Env("testcommand", root, "-h")
+		env.UsageWidth = 50
+
+		result := Capture(env, nil)
+		result.AssertValid(t)
+		result.AssertStdout(t, expected)
+	}
+
+	{ // width from COLUMNS
+		env := Env("testcommand", root, "-h")
+		env.Getenv = func(key string) string {
+			if key == "COLUMNS" {
+				return "50"
+			}
+			return ""
+		}
+
+		result := Capture(env, nil)
+		result.AssertValid(t)
+		result.AssertStdout(t, expected)
+	}
+
+	{ // wrapping disabled
+		env := Env("testcommand", root, "-h")
+		env.UsageWidth = -1
+		env.Getenv = func(key string) string { return "50" }
+
+		result := Capture(env, nil)
+		result.AssertValid(t)
+		result.AssertStdoutContains(t, "number of times to repeat the operation before giving up (default 1)\n")
+	}
+}
+
+func TestUsage_Color(t *testing.T) {
+	root := &funcCommand{
+		SetupFn: func(params clingy.Parameters) {
+			params.Flag("count", "number of times", 1, clingy.Transform(strconv.Atoi), clingy.Short('c'))
+			params.Arg("name", "the name")
+		},
+		ExecuteFn: func(ctx context.Context) error { return nil },
+	}
+
+	run := func(args ...string) Result {
+		env := Env("testcommand", root, args...)
+		env.Color = true
+		return Capture(env, nil)
+	}
+
+	{ // colors can be forced on while keeping alignment
+		result := run("-h", "--color=always")
+		result.AssertValid(t)
+		result.AssertStdout(t, "\x1b[1mUsage:\x1b[0m\n"+
+			"    testcommand [flags] <name>\n"+
+			"\n"+
+			"\x1b[1mArguments:\x1b[0m\n"+
+			"    \x1b[36mname\x1b[0m    the name\n"+
+			"\n"+
+			"\x1b[1mFlags:\x1b[0m\n"+
+			"    \x1b[36m-c, --count int\x1b[0m    number of times\x1b[2m (default 1)\x1b[0m\n"+
+			"\n"+
+			"\x1b[1mGlobal flags:\x1b[0m\n"+
+			"    \x1b[36m-h, --help \x1b[0m        prints help for the command\n"+
+			"    \x1b[36m    --summary \x1b[0m     prints a summary of what commands are available\n"+
+			"    \x1b[36m    --advanced \x1b[0m    when used with -h, prints advanced flags help\n")
+	}
+
+	{ // auto does not use colors when not a terminal
+		result := run("-h")
+		result.AssertValid(t)
+		result.AssertStdoutContains(t, "\nFlags:\n    -c, --count int    number of times (default 1)\n")
+	}
+
+	{ // errors are colored
+		result := run("--color", "always")
+		result.AssertStdoutContains(t, "\x1b[1mErrors:\x1b[0m\n    \x1b[31margument error: name: required argument missing\x1b[0m\n")
+	}
+
+	{ // the color flag only accepts some values
+		result := run("--color", "sometimes", "name")
+		result.AssertStdoutContains(t, `argument error: color: invalid value "sometimes": must be one of auto, always, never`)
+	}
+
+	{ // the color flag is advanced
+		result := run("-h", "--advanced", "--color=never")
+		result.AssertValid(t)
+		result.AssertStdoutContains(t, "        --color string    when to use colors in output (one of auto, always, never) (default \"auto\")\n")
+	}
+}
+
+func TestUsage_Examples(t *testing.T) {
+	cmds := func(cmds clingy.Commands) {
+		cmds.Group("files", "file commands", func() {
+			cmds.New("copy", "copy a file", &funcCommand{
+				SetupFn: func(params clingy.Parameters) {
+					params.Arg("src", "source")
+					params.Arg("dst", "destination")
+				},
+				ExecuteFn: func(ctx context.Context) error { return nil },
+			},
+				clingy.Example("a.txt b.txt", "copies a.txt to b.txt"),
+				clingy.Example("a.txt dir/", ""),
+			)
+		})
+	}
+
+	{
+		result := Capture(Env("tool", nil, "files", "copy", "-h"), cmds)
+		result.AssertValid(t)
+		result.AssertStdout(t, `
+			Usage:
+			    tool files copy <src> <dst>
+
+			    copy a file
+
+			Arguments:
+			    src    source
+			    dst    destination
+
+			Global flags:
+			    -h, --help         prints help for the command
+			        --summary      prints a summary of what commands are available
+			        --advanced     when used with -h, prints advanced flags help
+
+			Examples:
+			    tool files copy a.txt b.txt
+			        copies a.txt to b.txt
+
+			    tool files copy a.txt dir/
+		`)
+	}
+
+	{
+		env := Env("tool", nil, "--summary", "--examples")
+		env.Examples = true
+		result := Capture(env, cmds)
+		result.AssertValid(t)
+		result.AssertStdout(t, ""+
+			"Available commands:\n"+
+			"    tool files copy                    copy a file\n"+
+			"        tool files copy a.txt b.txt    copies a.txt to b.txt\n"+
+			"        tool files copy a.txt dir/\n")
+	}
+
+	{ // the flag is not defined unless enabled, so the name is free to use
+		result := Capture(Env("tool", nil, "files", "copy", "--examples", "a", "b"), func(cmds clingy.Commands) {
+			cmds.Flag("examples", "a global flag", false,
+				clingy.Boolean, clingy.Transform(strconv.ParseBool))
+			cmds.Group("files", "file commands", func() {
+				cmds.New("copy", "copy a file", &funcCommand{
+					SetupFn: func(params clingy.Parameters) {
+						params.Arg("src", "source")
+						params.Arg("dst", "destination")
+					},
+					ExecuteFn: func(ctx context.Context) error { return nil },
+				})
+			})
+		})
+		result.AssertValid(t)
+	}
+}
@@ -0,0 +1,125 @@
+package clingy
+
+import (
+	"errors"
+)
+
+// ValueSource provides values for flags. The Sources of an Environment are
+// consulted in order for every flag, and the first source with values for the
+// flag provides them.
+type ValueSource interface {
+	DynamicSource
+
+	// Keys returns the keys the source has values for, such as the names of
+	// the flags in a configuration file, for diagnostics.
+	Keys() ([]string, error)
+}
+
+// builtinSource is a ValueSource whose values depend on the state of a run.
+type builtinSource string
+
+var (
+	// CommandLine is the ValueSource for flags specified in the Args of the
+	// Environment. Flags on the command line are always consumed, even if a
+	// source before CommandLine provides the value. If CommandLine is not one
+	// of the Sources, specifying a flag on the command line is an error.
+	CommandLine ValueSource = builtinSource("command line")
+
+	// EnvVars is the ValueSource for environment variables bound to flags by
+	// the Getenv option or the EnvPrefix of the Environment. If it is not one
+	// of the Sources, environment variables are not used for arguments either.
+	EnvVars ValueSource = builtinSource("environment")
+
+	// DynamicValues is the ValueSource for the DynamicSource, or Dynamic, of
+	// the Environment.
+	DynamicValues ValueSource = builtinSource("dynamic")
+)
+
+// DefaultSources are the Sources used if the Environment does not specify any.
+var DefaultSources = []ValueSource{CommandLine, EnvVars, DynamicValues}
+
+// Lookup returns ErrNotFound because the values of the builtin sources are only
+// available while the Environment runs.
+func (builtinSource) Lookup(flag DynamicFlag) ([]string, error) { return nil, ErrNotFound }
+
+// Keys returns no keys because the values of the builtin sources are only
+// available while the Environment runs.
+func (builtinSource) Keys() ([]string, error) { return nil, nil }
+
+// lookupFlag returns the values for the flag from the first source in the chain
+// that has any, or nil if none do.
+func (ah *argsHandler) lookupFlag(p *param, flag DynamicFlag) ([]string, error) {
+	// the command line is always consumed so that the flags are not reported as
+	// unknown if another source takes precedence.
+	args, err := ah.ConsumeFlag(p.name, p.bstyle)
+	if err != nil {
+		return nil, err
+	} else if args == nil && p.short != 0 {
+		args, err = ah.ConsumeFlag(string(p.short), p.bstyle)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if args != nil && !ah.hasSource(CommandLine) {
+		if p.builtin {
+			return args, nil
+		}
+		return nil, invalidValue(p.name, "", nil, "%s: flag cannot be specified on the command line", p.name)
+	}
+
+	for _, src := range ah.valueSources() {
+		var vals []string
+		switch src {
+		case CommandLine:
+			vals = args
+		case EnvVars:
+			// only repeated flags can have multiple values from the environment.
+			envsep := ""
+			if p.rep {
+				envsep = p.envsep
+			}
+			vals = ah.LookupEnv(p.getenv, envsep)
+		case DynamicValues:
+			vals, err = ah.LookupDynamic(flag)
+		default:
+			vals, err = src.Lookup(flag)
+			if errors.Is(err, ErrNotFound) {
+				vals, err = nil, nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		} else if vals != nil {
+			return vals, nil
+		}
+	}
+	return nil, nil
+}
+
+// valueSources returns the chain of sources consulted for values.
+func (ah *argsHandler) valueSources() []ValueSource {
+	if ah.sources == nil {
+		return DefaultSources
+	}
+	return ah.sources
+}
+
+// hasSource returns true if the source is in the chain of sources.
+func (ah *argsHandler) hasSource(src ValueSource) bool {
+	for _, s := range ah.valueSources() {
+		if s == src {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupArgEnv returns the values of the environment variable for an argument
+// like LookupEnv, or nil if EnvVars is not in the chain of sources.
+func (ah *argsHandler) lookupArgEnv(key, sep string) []string {
+	if !ah.hasSource(EnvVars) {
+		return nil
+	}
+	return ah.LookupEnv(key, sep)
+}